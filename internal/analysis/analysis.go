@@ -0,0 +1,542 @@
+// Package analysis finds problems in programs without running them.
+//
+// Programs are interpreted abstractly: instead of values, the analysis tracks
+// how many values the stack can hold before each statement, which
+// statement pushed each value near the top of the stack, and the types
+// those values and the variables can have.
+package analysis
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/angelofallars/hypo/internal/ast"
+	errs "github.com/angelofallars/hypo/internal/errors"
+)
+
+// Severity is how serious a [Diagnostic] is.
+type Severity int
+
+const (
+	// SeverityError marks statements that always raise an error when they run.
+	SeverityError Severity = iota
+	// SeverityWarning marks likely mistakes that do not raise errors.
+	SeverityWarning
+)
+
+// Diagnostic is a problem found in a program.
+type Diagnostic struct {
+	Pos      ast.Position
+	Severity Severity
+	// Kind is the kind of error the statement raises when it runs.
+	// It is empty for warnings.
+	Kind    errs.ErrorKind
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	label := string(d.Kind)
+	if d.Severity == SeverityWarning {
+		label = "warning"
+	}
+	if d.Pos.IsValid() {
+		return fmt.Sprintf("%v: %v: %v", d.Pos, label, d.Message)
+	}
+	return fmt.Sprintf("%v: %v", label, d.Message)
+}
+
+// Config configures the analysis.
+type Config struct {
+	// Predefined holds the names of the variables defined before the program runs,
+	// besides true, false and null.
+	Predefined []string
+	// Types enables reporting operations that fail for every type their values can have.
+	Types bool
+	// Truthiness matches the truthiness option of the runtime,
+	// which lets any value be used as a condition.
+	Truthiness bool
+}
+
+// loop holds the states in which a loop being analyzed is left or restarted.
+type loop struct {
+	breaks    state
+	continues state
+	// literals is the number of array and object literals the loop is in.
+	literals int
+}
+
+type analyzer struct {
+	config Config
+	// defined holds every variable that is assigned anywhere in the program.
+	defined map[string]bool
+	// targets holds every label that is jumped to.
+	targets map[string]bool
+	// pushed and used hold the statements that push values,
+	// and those whose values are read on some path.
+	pushed map[ast.Node]bool
+	used   map[ast.Node]bool
+	// quiet is positive while searching for the states of loops,
+	// when diagnostics are not reported as the states are not final.
+	quiet int
+	loops []*loop
+	// literals holds the states before the array and object literals
+	// being analyzed, from the outermost one.
+	literals    []state
+	diagnostics map[Diagnostic]bool
+}
+
+// Check analyzes a program and returns the problems found, sorted by position.
+//
+// Errors are only reported for statements that raise an error every time
+// they run, such as stack underflows that happen on every path to a statement.
+func Check(program *ast.Program, config Config) []Diagnostic {
+	a := &analyzer{
+		config:      config,
+		defined:     map[string]bool{"true": true, "false": true, "null": true},
+		targets:     map[string]bool{},
+		pushed:      map[ast.Node]bool{},
+		used:        map[ast.Node]bool{},
+		diagnostics: map[Diagnostic]bool{},
+	}
+	for _, name := range config.Predefined {
+		a.defined[name] = true
+	}
+	a.collect(program.Statements)
+
+	a.block(emptyState(), program.Statements)
+
+	for node := range a.pushed {
+		if !a.used[node] {
+			a.report(node, SeverityWarning, "", "value pushed by %v is never used", describe(node))
+		}
+	}
+
+	diagnostics := make([]Diagnostic, 0, len(a.diagnostics))
+	for diagnostic := range a.diagnostics {
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	slices.SortFunc(diagnostics, func(a, b Diagnostic) int {
+		if a.Pos.Line != b.Pos.Line {
+			return cmp.Compare(a.Pos.Line, b.Pos.Line)
+		}
+		if a.Pos.Column != b.Pos.Column {
+			return cmp.Compare(a.Pos.Column, b.Pos.Column)
+		}
+		return cmp.Compare(a.Message, b.Message)
+	})
+	return diagnostics
+}
+
+// collect finds the variables that are assigned and the labels that are jumped to.
+func (a *analyzer) collect(statements []ast.Node) {
+	for _, statement := range statements {
+		switch node := statement.(type) {
+		case *ast.SetVariableStatement:
+			a.defined[node.Identifier] = true
+		case *ast.JumpStatement:
+			a.targets[node.Label] = true
+		}
+
+		for _, block := range children(statement) {
+			a.collect(block)
+		}
+	}
+}
+
+// children returns the blocks of statements nested in a statement.
+func children(node ast.Node) [][]ast.Node {
+	switch node := node.(type) {
+	case *ast.ArrayStatement:
+		blocks := [][]ast.Node{}
+		for _, element := range node.Elements {
+			blocks = append(blocks, element.Statements)
+		}
+		return blocks
+	case *ast.TableStatement:
+		blocks := [][]ast.Node{}
+		for _, row := range node.Rows {
+			blocks = append(blocks, row.Statements)
+		}
+		return blocks
+	case *ast.IfStatement:
+		return [][]ast.Node{node.Consequence, node.Alternative}
+	case *ast.LoopStatement:
+		return [][]ast.Node{node.Statements}
+	case *ast.FunctionStatement:
+		return [][]ast.Node{node.Statements}
+	}
+	return nil
+}
+
+// block analyzes a block of statements, returning the state after it.
+func (a *analyzer) block(s state, statements []ast.Node) state {
+	for _, statement := range statements {
+		// Jumps can reach labeled statements with any stack
+		if id := statement.Info().ID; id != "" && a.targets[id] {
+			a.markUsed(s.slots)
+			s = unknownState()
+		}
+		if s.reachable {
+			a.statement(&s, statement)
+		}
+	}
+	return s
+}
+
+// statement analyzes a single statement, updating the state.
+func (a *analyzer) statement(s *state, node ast.Node) {
+	switch node := node.(type) {
+	case *ast.NumberStatement:
+		a.push(s, node, numberType)
+	case *ast.StringStatement:
+		a.push(s, node, stringType)
+	case *ast.BoolStatement:
+		a.push(s, node, boolType)
+	case *ast.InputStatement:
+		a.push(s, node, inputResult(node))
+	case *ast.ArrayStatement:
+		for _, element := range node.Elements {
+			a.element(s, node, element.Statements)
+		}
+		a.push(s, node, arrayType)
+	case *ast.TableStatement:
+		for _, row := range node.Rows {
+			a.element(s, node, row.Statements)
+		}
+		a.push(s, node, objType)
+
+	case *ast.DuplicateStatement:
+		types := s.typesAt(0)
+		a.use(s, node, 1)
+		a.push(s, node, types)
+	case *ast.DeleteStatement:
+		a.pop(s, node, 1)
+	case *ast.SetVariableStatement:
+		types := s.typesAt(0)
+		a.pop(s, node, 1)
+		s.vars[node.Identifier] = types
+	case *ast.PrintStatement:
+		a.use(s, node, 1)
+	case *ast.BinaryOpStatement:
+		a.binary(s, node)
+	case *ast.NotStatement:
+		a.condition(s, node, "logical not")
+		a.push(s, node, boolType)
+	case *ast.GetPropertyStatement:
+		container := s.typesAt(0)
+		a.checkGet(node, container)
+		a.pop(s, node, 1)
+		a.push(s, node, propertyResult(container, node.Property))
+	case *ast.SetPropertyStatement:
+		a.checkSet(node, s.typesAt(1))
+		a.use(s, node, 2)
+		s.drop(1)
+	case *ast.GetDynamicPropertyStatement:
+		key, container := s.typesAt(0), s.typesAt(1)
+		if a.checkGet(node, container) {
+			a.checkKey(node, container, key)
+		}
+		a.pop(s, node, 2)
+		a.push(s, node, dynamicPropertyResult(container, key))
+	case *ast.SetDynamicPropertyStatement:
+		key, container := s.typesAt(1), s.typesAt(2)
+		if a.checkSet(node, container) {
+			a.checkKey(node, container, key)
+		}
+		a.use(s, node, 3)
+		s.drop(2)
+
+	case *ast.GetVariableStatement:
+		if !a.defined[node.Identifier] {
+			a.report(node, SeverityError, errs.VariableKind,
+				"variable '%v' is never defined", node.Identifier)
+		}
+		a.push(s, node, s.typeOf(node.Identifier))
+
+	case *ast.IfStatement:
+		a.condition(s, node, "conditional")
+		consequence := a.block(s.clone(), node.Consequence)
+		alternative := a.block(s.clone(), node.Alternative)
+		*s = a.join(consequence, alternative)
+	case *ast.LoopStatement:
+		a.loop(s, node)
+	case *ast.BreakStatement:
+		l := a.loops[len(a.loops)-1]
+		l.breaks = a.join(l.breaks, a.escape(*s, l))
+		s.reachable = false
+	case *ast.ContinueStatement:
+		l := a.loops[len(a.loops)-1]
+		l.continues = a.join(l.continues, a.escape(*s, l))
+		s.reachable = false
+	case *ast.JumpStatement:
+		if node.Conditional {
+			a.condition(s, node, "conditional jump")
+		}
+		// The values are on the stack at the label
+		a.markUsed(s.slots)
+		if !node.Conditional {
+			s.reachable = false
+		}
+
+	case *ast.FunctionStatement:
+		a.function(node)
+		a.push(s, node, functionType)
+	case *ast.CallStatement:
+		if function := s.typesAt(0); function&functionType == 0 {
+			a.typeError(node, "cannot call value of type '%v'", function)
+		}
+		a.pop(s, node, 1)
+		// Functions can do anything with the stack, but not with the caller's variables
+		a.markUsed(s.slots)
+		s.forgetStack()
+	}
+}
+
+// binary analyzes a binary operation, reporting operations that fail
+// for every possible combination of types.
+func (a *analyzer) binary(s *state, node *ast.BinaryOpStatement) {
+	right, left := s.typesAt(0), s.typesAt(1)
+	a.pop(s, node, 2)
+
+	result := binaryResult(node.Op, left, right, a.config.Truthiness)
+	if result != 0 {
+		a.push(s, node, result)
+		return
+	}
+
+	switch {
+	case node.Op == ast.BinAnd || node.Op == ast.BinOr:
+		operand := left
+		if left&boolType != 0 {
+			operand = right
+		}
+		a.typeError(node, "cannot perform %v on type '%v'", node.Op, operand)
+	case left == right:
+		a.typeError(node, "cannot perform %v on type '%v'", node.Op, left)
+	default:
+		a.typeError(node, "cannot perform %v on types '%v' and '%v'", node.Op, left, right)
+	}
+	a.push(s, node, anyType)
+}
+
+// condition pops the condition of a statement,
+// reporting conditions that can never be converted to a Bool.
+func (a *analyzer) condition(s *state, node ast.Node, operation string) {
+	if types := s.typesAt(0); !a.config.Truthiness && types&boolType == 0 {
+		a.typeError(node, "cannot perform %v on type '%v'", operation, types)
+	}
+	a.pop(s, node, 1)
+}
+
+// checkGet reports containers whose properties can never be read.
+func (a *analyzer) checkGet(node ast.Node, container typeSet) bool {
+	if container&(arrayType|stringType|objType) == 0 {
+		a.typeError(node, "cannot get property of type '%v'", container)
+		return false
+	}
+	return true
+}
+
+// checkSet reports containers whose properties can never be set.
+func (a *analyzer) checkSet(node ast.Node, container typeSet) bool {
+	if container&(arrayType|objType) == 0 {
+		a.typeError(node, "cannot set property of type '%v'", container)
+		return false
+	}
+	return true
+}
+
+// checkKey reports dynamic keys that can never be used with a container.
+func (a *analyzer) checkKey(node ast.Node, container, key typeSet) {
+	switch {
+	case container&^objType == 0 && key&stringType == 0:
+		a.typeError(node, "cannot use type '%v' as an object key", key)
+	case container&^(arrayType|stringType) == 0 && key&(numberType|stringType) == 0:
+		a.typeError(node, "cannot use type '%v' as an index", key)
+	}
+}
+
+// element analyzes an element of an array or object literal,
+// which leaves the stack unchanged but can assign variables.
+func (a *analyzer) element(s *state, node ast.Node, statements []ast.Node) {
+	a.literals = append(a.literals, s.clone())
+	end := a.block(s.clone(), statements)
+	a.literals = a.literals[:len(a.literals)-1]
+
+	if end.reachable && end.maxHeight() < 1 {
+		a.report(node, SeverityError, errs.StackKind,
+			"element of %v leaves no value on the stack", describe(node))
+	}
+	if len(end.slots) > 0 {
+		a.markUsed(end.top(1))
+	}
+	if end.reachable {
+		s.vars = end.vars
+	}
+}
+
+// loop analyzes a loop, finding the state at the start of each iteration
+// by analyzing the body until the state stops changing.
+func (a *analyzer) loop(s *state, node *ast.LoopStatement) {
+	entry := s.clone()
+	head := entry
+
+	a.quiet++
+	for i := 0; ; i++ {
+		body, _ := a.iteration(head, node)
+		next := a.join(entry, body)
+		if i >= 2 {
+			next = widen(head, next)
+		}
+		if i >= 10 {
+			a.markUsed(next.slots)
+			next = unknownState()
+		}
+		if next.equal(head) {
+			break
+		}
+		head = next
+	}
+	a.quiet--
+
+	// Analyze the loop once more with the final state to report diagnostics
+	_, breaks := a.iteration(head, node)
+
+	exit := head.clone()
+	exit.drop(1)
+	*s = a.join(exit, breaks)
+}
+
+// iteration analyzes an iteration of a loop starting from a state.
+// It returns the state before the next iteration, and the state after breaking out of the loop.
+func (a *analyzer) iteration(head state, node *ast.LoopStatement) (next state, breaks state) {
+	s := head.clone()
+	a.condition(&s, node, "loop")
+
+	l := &loop{literals: len(a.literals)}
+	a.loops = append(a.loops, l)
+	end := a.block(s, node.Statements)
+	a.loops = a.loops[:len(a.loops)-1]
+
+	return a.join(end, l.continues), l.breaks
+}
+
+// escape returns the state of the stack when a <br> or <rb> leaves a block.
+func (a *analyzer) escape(s state, l *loop) state {
+	// Literals left by a <br> or <rb> discard the values their statements pushed,
+	// so the stack is as it was before the outermost literal that is left
+	if len(a.literals) > l.literals {
+		a.markUsed(s.slots)
+		left := a.literals[l.literals].clone()
+		left.vars = s.vars
+		return left
+	}
+	return s
+}
+
+// function analyzes the body of a function, which can be called with any stack.
+func (a *analyzer) function(node *ast.FunctionStatement) {
+	loops, literals := a.loops, a.literals
+	a.loops, a.literals = nil, nil
+
+	end := a.block(unknownState(), node.Statements)
+	// Values left on the stack are returned to the caller
+	a.markUsed(end.slots)
+
+	a.loops, a.literals = loops, literals
+}
+
+// push pushes a value from a statement.
+func (a *analyzer) push(s *state, node ast.Node, types typeSet) {
+	a.pushed[node] = true
+	s.push(node, types)
+}
+
+// pop pops values that a statement reads.
+func (a *analyzer) pop(s *state, node ast.Node, count int) {
+	a.use(s, node, count)
+	s.drop(count)
+}
+
+// use reads values at the top of the stack without popping them,
+// reporting an error if the stack never holds enough values.
+func (a *analyzer) use(s *state, node ast.Node, count int) {
+	if height := s.maxHeight(); height < count {
+		a.report(node, SeverityError, errs.StackKind,
+			"%v needs %v on the stack, but there %v", describe(node), values(count), atMost(height))
+	}
+	a.markUsed(s.top(count))
+}
+
+func (a *analyzer) markUsed(slots []slot) {
+	for _, slot := range slots {
+		if slot.origin != nil {
+			a.used[slot.origin] = true
+		}
+	}
+}
+
+// join joins two states, marking values whose origin is lost as used.
+func (a *analyzer) join(x, y state) state {
+	if x.reachable && y.reachable {
+		count := min(len(x.slots), len(y.slots))
+		a.markUsed(x.slots[:len(x.slots)-count])
+		a.markUsed(y.slots[:len(y.slots)-count])
+		yTop := y.top(count)
+		for i, xSlot := range x.top(count) {
+			if xSlot.origin != yTop[i].origin {
+				a.markUsed([]slot{xSlot, yTop[i]})
+			}
+		}
+	}
+	return join(x, y)
+}
+
+// typeError reports a TypeError if types are checked.
+func (a *analyzer) typeError(node ast.Node, message string, format ...any) {
+	if a.config.Types {
+		a.report(node, SeverityError, errs.TypeKind, message, format...)
+	}
+}
+
+func (a *analyzer) report(node ast.Node, severity Severity, kind errs.ErrorKind, message string, format ...any) {
+	if a.quiet > 0 {
+		return
+	}
+	a.diagnostics[Diagnostic{
+		Pos:      node.Info().Pos,
+		Severity: severity,
+		Kind:     kind,
+		Message:  fmt.Sprintf(message, format...),
+	}] = true
+}
+
+// describe returns the element of a statement,
+// or only its start tag if the element has child elements.
+func describe(node ast.Node) string {
+	text := node.String()
+	end := strings.Index(text, ">")
+	if end == -1 || strings.Count(text, "<") <= 2 {
+		return text
+	}
+	return text[:end+1]
+}
+
+func values(count int) string {
+	if count == 1 {
+		return "1 value"
+	}
+	return fmt.Sprintf("%v values", count)
+}
+
+func atMost(height int) string {
+	switch height {
+	case 0:
+		return "are none"
+	case 1:
+		return "is at most 1"
+	}
+	return fmt.Sprintf("are at most %v", height)
+}
@@ -0,0 +1,178 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/angelofallars/hypo/internal/parser"
+)
+
+// check parses and analyzes source code, returning its diagnostics as strings.
+func check(t *testing.T, source string, config Config) []string {
+	t.Helper()
+
+	program, err := parser.Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostics := []string{}
+	for _, diagnostic := range Check(program, config) {
+		diagnostics = append(diagnostics, diagnostic.String())
+	}
+	return diagnostics
+}
+
+type checkTest struct {
+	name   string
+	source string
+	config Config
+	want   []string
+}
+
+func runCheckTests(t *testing.T, tests []checkTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := check(t, tt.source, tt.config); !slices.Equal(got, tt.want) {
+				t.Errorf("diagnostics of %q\ngot:  %q\nwant: %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	runCheckTests(t, []checkTest{
+		{
+			name:   "valid program",
+			source: `<s>a</s><var title="x"></var><cite>x</cite><output></output><del></del>`,
+			want:   []string{},
+		},
+
+		// Stack underflows
+		{
+			name:   "underflow on an empty stack",
+			source: `<output></output>`,
+			want:   []string{"1:1: StackError: <output></output> needs 1 value on the stack, but there are none"},
+		},
+		{
+			name:   "underflow after popping",
+			source: `<data value="1"></data><del></del><del></del>`,
+			want:   []string{"1:35: StackError: <del></del> needs 1 value on the stack, but there are none"},
+		},
+		{
+			name:   "binary operation with one value",
+			source: `<data value="1"></data><dd></dd><output></output>`,
+			want:   []string{"1:24: StackError: <dd></dd> needs 2 values on the stack, but there is at most 1"},
+		},
+		{
+			name:   "underflow on only one branch",
+			source: `<cite>true</cite><i><data value="1"></data></i><output></output>`,
+			want:   []string{},
+		},
+		{
+			name:   "function bodies can use the caller's stack",
+			source: `<dfn><dd></dd></dfn><data value="1"></data><data value="2"></data><dt></dt><code></code><output></output>`,
+			want:   []string{},
+		},
+
+		// Undefined variables
+		{
+			name:   "variable never defined",
+			source: `<cite>x</cite><output></output>`,
+			want:   []string{"1:1: VariableError: variable 'x' is never defined"},
+		},
+		{
+			name:   "variable defined later in a loop",
+			source: `<cite>true</cite><rt><cite>x</cite><del></del><data value="1"></data><var title="x"></var><cite>false</cite></rt>`,
+			want:   []string{},
+		},
+		{
+			name:   "predefined variable",
+			source: `<cite>x</cite><output></output>`,
+			config: Config{Predefined: []string{"x"}},
+			want:   []string{},
+		},
+
+		// Unused values
+		{
+			name:   "value left on the stack",
+			source: `<s>a</s>`,
+			want:   []string{"1:1: warning: value pushed by <s>a</s> is never used"},
+		},
+		{
+			name:   "value deleted",
+			source: `<s>a</s><del></del>`,
+			want:   []string{},
+		},
+		{
+			name:   "value used on one branch",
+			source: `<s>a</s><cite>true</cite><i><output></output></i><data value="1"></data>`,
+			want:   []string{"1:50: warning: value pushed by <data value=\"1\"></data> is never used"},
+		},
+		{
+			name:   "value passed to a function",
+			source: `<dfn><output></output></dfn><var title="f"></var><s>a</s><cite>f</cite><code></code>`,
+			want:   []string{},
+		},
+		{
+			name:   "value left at a jump",
+			source: `<s>a</s><a href="#end"></a><s>b</s><del></del><output id="end"></output>`,
+			want:   []string{},
+		},
+
+		// Merging branches and loops
+		{
+			name:   "branches push the same number of values",
+			source: `<cite>true</cite><i><s>a</s><s>b</s></i><output></output>`,
+			want:   []string{},
+		},
+		{
+			name:   "stack grows in a loop",
+			source: `<data value="0"></data><cite>true</cite><rt><data value="1"></data><cite>true</cite></rt><output></output>`,
+			want:   []string{},
+		},
+		{
+			name:   "loop body underflows on the first iteration",
+			source: `<cite>true</cite><rt><del></del><cite>true</cite></rt>`,
+			want:   []string{"1:22: StackError: <del></del> needs 1 value on the stack, but there are none"},
+		},
+		{
+			name:   "break leaves a loop",
+			source: `<cite>true</cite><rt><s>a</s><br></rt><output></output>`,
+			want:   []string{},
+		},
+		{
+			name:   "break leaves an array literal",
+			source: `<cite>true</cite><rt><ol><li><s>a</s><br></li></ol><del></del><cite>false</cite></rt><output></output>`,
+			want:   []string{"1:86: StackError: <output></output> needs 1 value on the stack, but there are none"},
+		},
+
+		// Conditions
+		{
+			name:   "condition that is never a Bool",
+			source: `<data value="1"></data><i><s>a</s><output></output></i>`,
+			config: Config{Types: true},
+			want:   []string{"1:24: TypeError: cannot perform conditional on type 'Number'"},
+		},
+		{
+			name:   "condition with truthiness",
+			source: `<data value="1"></data><i><s>a</s><output></output></i>`,
+			config: Config{Types: true, Truthiness: true},
+			want:   []string{},
+		},
+		{
+			name:   "condition without type checks",
+			source: `<data value="1"></data><i><s>a</s><output></output></i>`,
+			want:   []string{},
+		},
+		{
+			name:   "binary operation on mismatched types",
+			source: `<s>a</s><data value="1"></data><sub></sub><output></output>`,
+			config: Config{Types: true},
+			want:   []string{"1:32: TypeError: cannot perform subtraction on types 'String' and 'Number'"},
+		},
+	})
+}
@@ -0,0 +1,192 @@
+package analysis
+
+import (
+	"maps"
+	"math"
+
+	"github.com/angelofallars/hypo/internal/ast"
+)
+
+// unbounded is the maximum of an [interval] with no upper bound.
+const unbounded = math.MaxInt
+
+// interval is a range of possible stack heights.
+type interval struct {
+	min int
+	max int
+}
+
+// slot is a value on the stack whose origin is tracked.
+type slot struct {
+	// origin is the statement that pushed the value, or nil if it could be
+	// one of several statements.
+	origin ast.Node
+	types  typeSet
+}
+
+// state is the abstract state of the program before a statement runs.
+type state struct {
+	// reachable is false for statements that can never run,
+	// such as those following a <br>.
+	reachable bool
+	// slots are the values at the top of the stack, from bottom to top.
+	slots []slot
+	// below is the number of untracked values under the slots,
+	// which can have any type.
+	below interval
+	// vars holds the types of the variables in the current scope.
+	// Variables that are not in the map can have any type.
+	vars map[string]typeSet
+}
+
+// emptyState returns the state of a program that has not run yet.
+func emptyState() state {
+	return state{
+		reachable: true,
+		slots:     []slot{},
+		vars:      map[string]typeSet{"true": boolType, "false": boolType, "null": nullType},
+	}
+}
+
+// unknownState returns the state of a stack that can hold any number of values,
+// and variables that can have any type.
+func unknownState() state {
+	return state{
+		reachable: true,
+		slots:     []slot{},
+		below:     interval{0, unbounded},
+		vars:      map[string]typeSet{},
+	}
+}
+
+func (s state) clone() state {
+	s.slots = append([]slot{}, s.slots...)
+	s.vars = maps.Clone(s.vars)
+	return s
+}
+
+// maxHeight returns the maximum number of values the stack can hold.
+func (s state) maxHeight() int {
+	if s.below.max == unbounded {
+		return unbounded
+	}
+	return s.below.max + len(s.slots)
+}
+
+// push adds a value pushed by a statement to the top of the stack.
+func (s *state) push(origin ast.Node, types typeSet) {
+	s.slots = append(s.slots, slot{origin: origin, types: types})
+}
+
+// drop removes values from the top of the stack.
+//
+// If the stack cannot hold that many values, it is left empty
+// so that the statements after an underflow can still be analyzed.
+func (s *state) drop(count int) {
+	for ; count > 0 && len(s.slots) > 0; count-- {
+		s.slots = s.slots[:len(s.slots)-1]
+	}
+	s.below.min = max(s.below.min-count, 0)
+	if s.below.max != unbounded {
+		s.below.max = max(s.below.max-count, 0)
+	}
+}
+
+// top returns up to count tracked values at the top of the stack.
+func (s state) top(count int) []slot {
+	return s.slots[max(len(s.slots)-count, 0):]
+}
+
+// typesAt returns the types of a value in the stack,
+// where a depth of 0 is the top of the stack.
+func (s state) typesAt(depth int) typeSet {
+	if depth < len(s.slots) {
+		return s.slots[len(s.slots)-1-depth].types
+	}
+	return anyType
+}
+
+// typeOf returns the types of a variable.
+func (s state) typeOf(identifier string) typeSet {
+	if types, ok := s.vars[identifier]; ok {
+		return types
+	}
+	return anyType
+}
+
+// forgetStack makes the stack hold any number of values of any type,
+// keeping the types of variables.
+func (s *state) forgetStack() {
+	s.slots = []slot{}
+	s.below = interval{0, unbounded}
+}
+
+// join returns a state that holds the possibilities of both states,
+// for statements that can be reached from either of them.
+//
+// The values at the top of both stacks are matched with each other,
+// and the rest become untracked. The origin of matched values that
+// were pushed by different statements is lost.
+func join(a, b state) state {
+	if !a.reachable {
+		return b.clone()
+	}
+	if !b.reachable {
+		return a.clone()
+	}
+
+	count := min(len(a.slots), len(b.slots))
+	aTop, bTop := a.top(count), b.top(count)
+
+	joined := state{reachable: true, slots: make([]slot, count), vars: map[string]typeSet{}}
+	for i := range joined.slots {
+		joined.slots[i].types = aTop[i].types | bTop[i].types
+		if aTop[i].origin == bTop[i].origin {
+			joined.slots[i].origin = aTop[i].origin
+		}
+	}
+
+	aBelow := addInterval(a.below, len(a.slots)-count)
+	bBelow := addInterval(b.below, len(b.slots)-count)
+	joined.below = interval{min(aBelow.min, bBelow.min), max(aBelow.max, bBelow.max)}
+
+	for identifier, types := range a.vars {
+		if other, ok := b.vars[identifier]; ok {
+			joined.vars[identifier] = types | other
+		}
+	}
+
+	return joined
+}
+
+// widen makes the bounds of a loop's state that keep changing unbounded,
+// so that analyzing the loop terminates.
+func widen(old, new state) state {
+	if new.below.max > old.below.max {
+		new.below.max = unbounded
+	}
+	if new.below.min < old.below.min {
+		new.below.min = 0
+	}
+	return new
+}
+
+func (s state) equal(other state) bool {
+	if s.reachable != other.reachable || s.below != other.below || len(s.slots) != len(other.slots) {
+		return false
+	}
+	for i := range s.slots {
+		if s.slots[i] != other.slots[i] {
+			return false
+		}
+	}
+	return maps.Equal(s.vars, other.vars)
+}
+
+func addInterval(i interval, count int) interval {
+	if i.max != unbounded {
+		i.max += count
+	}
+	i.min += count
+	return i
+}
@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/angelofallars/hypo/internal/ast"
+	"github.com/angelofallars/hypo/internal/object"
+)
+
+// typeSet is the set of types a value can have.
+type typeSet uint8
+
+const (
+	numberType typeSet = 1 << iota
+	stringType
+	boolType
+	objType
+	nullType
+	arrayType
+	functionType
+
+	// anyType is the set of every type, for values that are not tracked.
+	anyType = numberType | stringType | boolType | objType | nullType | arrayType | functionType
+)
+
+var typeNames = []struct {
+	types typeSet
+	name  object.ObjectType
+}{
+	{numberType, object.NumberType},
+	{stringType, object.StringType},
+	{boolType, object.BoolType},
+	{objType, object.ObjType},
+	{nullType, object.NullType},
+	{arrayType, object.ArrayType},
+	{functionType, object.FunctionType},
+}
+
+// String returns the names of the types, separated by ' | '.
+func (t typeSet) String() string {
+	names := []string{}
+	for _, typeName := range typeNames {
+		if t&typeName.types != 0 {
+			names = append(names, string(typeName.name))
+		}
+	}
+	return strings.Join(names, " | ")
+}
+
+// each returns the single types in the set.
+func (t typeSet) each() []typeSet {
+	types := []typeSet{}
+	for _, typeName := range typeNames {
+		if t&typeName.types != 0 {
+			types = append(types, typeName.types)
+		}
+	}
+	return types
+}
+
+// binaryResult returns the types of the result of a binary operation,
+// which is empty if the operation fails for every combination of types.
+func binaryResult(op ast.BinaryOp, left, right typeSet, truthiness bool) typeSet {
+	var result typeSet
+	for _, l := range left.each() {
+		for _, r := range right.each() {
+			result |= binaryPairResult(op, l, r, truthiness)
+		}
+	}
+	return result
+}
+
+// binaryPairResult returns the type of the result of a binary operation
+// on values of single types, or zero if the operation fails.
+//
+// This follows the rules of evalBinOp in the evaluator.
+func binaryPairResult(op ast.BinaryOp, left, right typeSet, truthiness bool) typeSet {
+	switch op {
+	case ast.BinAdd:
+		if left == right && (left == numberType || left == stringType) {
+			return left
+		}
+	case ast.BinSubtract, ast.BinMultiply, ast.BinDivide:
+		if left == numberType && right == numberType {
+			return numberType
+		}
+	case ast.BinGreaterThan, ast.BinLessThan:
+		if left == right && (left == numberType || left == stringType) {
+			return boolType
+		}
+	case ast.BinEqual:
+		if left == right || left == nullType || right == nullType {
+			return boolType
+		}
+	case ast.BinAnd, ast.BinOr:
+		if truthiness || (left == boolType && right == boolType) {
+			return boolType
+		}
+	}
+	return 0
+}
+
+// propertyResult returns the types of a property of a container.
+func propertyResult(container typeSet, property string) typeSet {
+	switch {
+	case property == "length" && container&^(arrayType|stringType) == 0:
+		return numberType
+	case container == stringType:
+		return stringType
+	}
+	return anyType
+}
+
+// dynamicPropertyResult returns the types of a property of a container
+// read with a key from the stack.
+func dynamicPropertyResult(container, key typeSet) typeSet {
+	if container == stringType {
+		if key&stringType != 0 {
+			// The key could be 'length'
+			return stringType | numberType
+		}
+		return stringType
+	}
+	return anyType
+}
+
+// inputResult returns the types of the values pushed by an <input>.
+func inputResult(node *ast.InputStatement) typeSet {
+	// Null is pushed once there is no more input
+	if node.Number {
+		return numberType | nullType
+	}
+	return stringType | nullType
+}
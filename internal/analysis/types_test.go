@@ -0,0 +1,186 @@
+package analysis
+
+import "testing"
+
+func TestCheckTypes(t *testing.T) {
+	types := Config{Types: true}
+
+	runCheckTests(t, []checkTest{
+		// Calls
+		{
+			name:   "call of a number",
+			source: `<data value="1"></data><code></code>`,
+			config: types,
+			want:   []string{"1:24: TypeError: cannot call value of type 'Number'"},
+		},
+		{
+			name:   "call of a function",
+			source: `<dfn><s>a</s><output></output></dfn><code></code>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "call of a variable that can be a function",
+			source: `<cite>true</cite><i><dfn></dfn><hr><data value="1"></data></i><var title="f"></var><cite>f</cite><code></code>`,
+			config: types,
+			want:   []string{},
+		},
+
+		// Binary operations
+		{
+			name:   "subtraction of strings",
+			source: `<s>a</s><s>b</s><sub></sub><output></output>`,
+			config: types,
+			want:   []string{"1:17: TypeError: cannot perform subtraction on type 'String'"},
+		},
+		{
+			name:   "addition of strings",
+			source: `<s>a</s><s>b</s><dd></dd><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "logical and of a number",
+			source: `<cite>true</cite><data value="1"></data><b></b><output></output>`,
+			config: types,
+			want:   []string{"1:41: TypeError: cannot perform logical and on type 'Number'"},
+		},
+		{
+			name:   "logical and with truthiness",
+			source: `<cite>true</cite><data value="1"></data><b></b><output></output>`,
+			config: Config{Types: true, Truthiness: true},
+			want:   []string{},
+		},
+		{
+			name:   "comparison with null",
+			source: `<cite>null</cite><data value="1"></data><em></em><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "addition of input and a number",
+			source: `<input><data value="1"></data><dd></dd><output></output>`,
+			config: types,
+			want:   []string{"1:31: TypeError: cannot perform addition on types 'String | Null' and 'Number'"},
+		},
+		{
+			name:   "addition of a number input and a number",
+			source: `<input type="number"><data value="1"></data><dd></dd><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "variable assigned on both branches",
+			source: `<cite>true</cite><i><data value="1"></data><hr><s>a</s></i><var title="x"></var><cite>x</cite><cite>true</cite><dd></dd><output></output>`,
+			config: types,
+			want:   []string{"1:112: TypeError: cannot perform addition on types 'Number | String' and 'Bool'"},
+		},
+		{
+			name:   "variable that can have the right type",
+			source: `<cite>true</cite><i><data value="1"></data><hr><s>a</s></i><var title="x"></var><cite>x</cite><data value="1"></data><dd></dd><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "type changed in a loop",
+			source: `<data value="1"></data><var title="x"></var><cite>true</cite><rt><s>a</s><var title="x"></var><cite>false</cite></rt><cite>x</cite><data value="1"></data><sub></sub><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+
+		// Conditions
+		{
+			name:   "logical not of a string",
+			source: `<s>a</s><bdi></bdi><output></output>`,
+			config: types,
+			want:   []string{"1:9: TypeError: cannot perform logical not on type 'String'"},
+		},
+		{
+			name:   "logical not of a comparison",
+			source: `<data value="1"></data><data value="2"></data><small></small><bdi></bdi><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "loop condition that is never a Bool",
+			source: `<s>a</s><rt><s>b</s></rt>`,
+			config: types,
+			want:   []string{"1:9: TypeError: cannot perform loop on type 'String'"},
+		},
+		{
+			name:   "conditional jump on a number",
+			source: `<data value="1"></data><a href="#end" data-if></a><s id="end">end</s><output></output>`,
+			config: types,
+			want:   []string{"1:24: TypeError: cannot perform conditional jump on type 'Number'"},
+		},
+		{
+			name:   "conditional jump on a Bool",
+			source: `<cite>true</cite><a href="#end" data-if></a><s id="end">end</s><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+
+		// Properties
+		{
+			name:   "property of a number",
+			source: `<data value="1"></data><rp title="x"></rp><output></output>`,
+			config: types,
+			want:   []string{"1:24: TypeError: cannot get property of type 'Number'"},
+		},
+		{
+			name:   "length of a string",
+			source: `<s>abc</s><rp title="length"></rp><data value="1"></data><sub></sub><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "setting a property of a string",
+			source: `<s>a</s><data value="1"></data><samp title="x"></samp><output></output>`,
+			config: types,
+			want:   []string{"1:32: TypeError: cannot set property of type 'String'"},
+		},
+		{
+			name:   "setting a property of an object",
+			source: `<table></table><data value="1"></data><samp title="x"></samp><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "object key that is a number",
+			source: `<table></table><data value="1"></data><address></address><output></output>`,
+			config: types,
+			want:   []string{"1:39: TypeError: cannot use type 'Number' as an object key"},
+		},
+		{
+			name:   "object key that is a string",
+			source: `<table></table><s>x</s><address></address><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "array index that is a Bool",
+			source: `<ol></ol><cite>true</cite><data value="1"></data><ins></ins><output></output>`,
+			config: types,
+			want:   []string{"1:50: TypeError: cannot use type 'Bool' as an index"},
+		},
+		{
+			name:   "array index that is a number",
+			source: `<ol><li><s>a</s></li></ol><data value="0"></data><s>b</s><ins></ins><output></output>`,
+			config: types,
+			want:   []string{},
+		},
+		{
+			name:   "dynamic property of a Bool",
+			source: `<cite>true</cite><s>x</s><address></address><output></output>`,
+			config: types,
+			want:   []string{"1:26: TypeError: cannot get property of type 'Bool'"},
+		},
+
+		// Type checks are opt-in
+		{
+			name:   "type errors without type checks",
+			source: `<data value="1"></data><code></code><s>a</s><s>b</s><sub></sub><output></output>`,
+			want:   []string{},
+		},
+	})
+}
@@ -2,6 +2,8 @@ package ast
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/angelofallars/hypo/pkg/sliceutil"
@@ -11,9 +13,104 @@ type Node interface {
 	// Dummy function
 	astNode()
 	String() string
+	// Info returns the metadata shared by all nodes.
+	Info() *NodeInfo
 }
 
+// NodeInfo holds metadata shared by all nodes.
+type NodeInfo struct {
+	// ID is the id attribute of the element the node was parsed from.
+	// It is used as a label for jumps.
+	ID string
+	// Pos is the position of the element's start tag in the source code.
+	Pos Position
+	// Layout is the layout of the source code before the element's start tag.
+	Layout Layout
+	// EndLayout is the layout of the source code before the element's end tag,
+	// for elements that hold statements.
+	EndLayout Layout
+}
+
+// Layout holds the comments and line breaks between two tags in the source code,
+// which do not affect how a program runs but are kept when formatting it.
+type Layout struct {
+	Comments []Comment
+	// Newlines is the number of line breaks between the last comment,
+	// or the previous tag if there are no comments, and the next tag.
+	Newlines int
+}
+
+// Comment is an HTML comment.
+type Comment struct {
+	// Text is the text between the <!-- and -->.
+	Text string
+	// Newlines is the number of line breaks between the comment and
+	// whatever precedes it.
+	Newlines int
+}
+
+func (c Comment) String() string {
+	return "<!--" + c.Text + "-->"
+}
+
+var (
+	textEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attributeEscaper = strings.NewReplacer("&", "&amp;", `"`, "&quot;")
+)
+
+// StartTag returns the start tag of the element, with its id and the given attributes.
+func (ni *NodeInfo) StartTag(name string, attrs ...string) string {
+	var b strings.Builder
+	b.WriteString("<" + name)
+	if ni.ID != "" {
+		b.WriteString(` id="` + attributeEscaper.Replace(ni.ID) + `"`)
+	}
+	for _, attr := range attrs {
+		b.WriteString(" " + attr)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// EscapeText escapes the special characters of text in an element.
+func EscapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// attr returns an attribute with an escaped value.
+func attr(name, value string) string {
+	return name + `="` + attributeEscaper.Replace(value) + `"`
+}
+
+// formatNumber formats a number in the shortest form that parses back
+// to the same value, only using an exponent for very large or small numbers.
+func formatNumber(value float64) string {
+	if abs := math.Abs(value); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Position is a location in the source code.
+//
+// Lines and columns start from 1. The zero value is an unknown position.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// IsValid checks if the position is known.
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+func (p Position) String() string {
+	return fmt.Sprintf("%v:%v", p.Line, p.Column)
+}
+
+func (ni *NodeInfo) Info() *NodeInfo { return ni }
+
 type Program struct {
+	NodeInfo
+
 	Statements []Node
 }
 
@@ -24,71 +121,154 @@ func (p *Program) String() string {
 }
 
 type NumberStatement struct {
+	NodeInfo
+
 	Value float64
 }
 
 func (ns *NumberStatement) astNode() {}
 func (ns *NumberStatement) String() string {
-	return fmt.Sprintf(`<data value="%f"></data>`, ns.Value)
+	return ns.StartTag("data", attr("value", formatNumber(ns.Value))) + "</data>"
 }
 
 type StringStatement struct {
+	NodeInfo
+
 	Value string
 }
 
 func (ss *StringStatement) astNode() {}
 func (ss *StringStatement) String() string {
-	return fmt.Sprintf(`<s>%s</s>`, ss.Value)
+	return ss.StartTag("s") + EscapeText(ss.Value) + "</s>"
 }
 
 type BoolStatement struct {
+	NodeInfo
+
 	Value bool
 }
 
 func (bs *BoolStatement) astNode() {}
 func (bs *BoolStatement) String() string {
-	return fmt.Sprintf(`<cite>%v</cite>`, bs.Value)
+	return bs.StartTag("cite") + strconv.FormatBool(bs.Value) + "</cite>"
 }
 
 type ArrayStatement struct {
+	NodeInfo
+
 	Elements []*ArrayElementStatement
 }
 
 func (as *ArrayStatement) astNode() {}
 func (as *ArrayStatement) String() string {
 	childStrings := sliceutil.Map(as.Elements, func(stmt *ArrayElementStatement) string { return stmt.String() })
-	return fmt.Sprintf(`<ol>%v</ol>`, strings.Join(childStrings, ""))
+	return as.StartTag("ol") + strings.Join(childStrings, "") + "</ol>"
 }
 
 type ArrayElementStatement struct {
+	NodeInfo
+
 	Statements []Node
 }
 
 func (aes *ArrayElementStatement) astNode() {}
 func (aes *ArrayElementStatement) String() string {
 	childStrings := sliceutil.Map(aes.Statements, func(stmt Node) string { return stmt.String() })
-	return fmt.Sprintf(`<li>%v</li>`, strings.Join(childStrings, ""))
+	return aes.StartTag("li") + strings.Join(childStrings, "") + "</li>"
+}
+
+type TableStatement struct {
+	NodeInfo
+
+	Rows []*TableRowStatement
+}
+
+func (ts *TableStatement) astNode() {}
+func (ts *TableStatement) String() string {
+	childStrings := sliceutil.Map(ts.Rows, func(stmt *TableRowStatement) string { return stmt.String() })
+	return ts.StartTag("table") + strings.Join(childStrings, "") + "</table>"
+}
+
+type TableRowStatement struct {
+	NodeInfo
+
+	Key        string
+	Statements []Node
+}
+
+func (trs *TableRowStatement) astNode() {}
+func (trs *TableRowStatement) String() string {
+	childStrings := sliceutil.Map(trs.Statements, func(stmt Node) string { return stmt.String() })
+	return trs.StartTag("tr") + "<th>" + EscapeText(trs.Key) + "</th><td>" +
+		strings.Join(childStrings, "") + "</td></tr>"
 }
 
-type DuplicateStatement struct{}
+type DuplicateStatement struct {
+	NodeInfo
+}
 
 func (ds *DuplicateStatement) astNode() {}
 func (ds *DuplicateStatement) String() string {
-	return "<dt></dt>"
+	return ds.StartTag("dt") + "</dt>"
 }
 
-type DeleteStatement struct{}
+type DeleteStatement struct {
+	NodeInfo
+}
 
 func (ds *DeleteStatement) astNode() {}
 func (ds *DeleteStatement) String() string {
-	return "<del></del>"
+	return ds.StartTag("del") + "</del>"
 }
 
-type PrintStatement struct{}
+type PrintStatement struct {
+	NodeInfo
+
+	// Raw prints strings without quotes.
+	Raw bool
+	// NoNewline omits the newline after the value.
+	NoNewline bool
+	// Stderr prints to stderr instead of stdout.
+	Stderr bool
+}
 
 func (os *PrintStatement) astNode() {}
 func (os *PrintStatement) String() string {
-	return "<output></output>"
+	attrs := []string{}
+	if os.Stderr {
+		attrs = append(attrs, attr("for", "stderr"))
+	}
+	if os.Raw {
+		attrs = append(attrs, "data-raw")
+	}
+	if os.NoNewline {
+		attrs = append(attrs, "data-no-newline")
+	}
+	return os.StartTag("output", attrs...) + "</output>"
+}
+
+type InputStatement struct {
+	NodeInfo
+
+	// Number parses the input as a Number instead of a String.
+	Number bool
+}
+
+func (is *InputStatement) astNode() {}
+func (is *InputStatement) String() string {
+	if is.Number {
+		return is.StartTag("input", attr("type", "number"))
+	}
+	return is.StartTag("input")
+}
+
+type BreakpointStatement struct {
+	NodeInfo
+}
+
+func (bs *BreakpointStatement) astNode() {}
+func (bs *BreakpointStatement) String() string {
+	return bs.StartTag("wbr")
 }
 
 type BinaryOp uint
@@ -98,6 +278,11 @@ const (
 	BinSubtract
 	BinMultiply
 	BinDivide
+	BinGreaterThan
+	BinLessThan
+	BinEqual
+	BinAnd
+	BinOr
 )
 
 func (bo BinaryOp) String() string {
@@ -110,11 +295,23 @@ func (bo BinaryOp) String() string {
 		return "multiplication"
 	case BinDivide:
 		return "division"
+	case BinGreaterThan:
+		return "greater than comparison"
+	case BinLessThan:
+		return "less than comparison"
+	case BinEqual:
+		return "equality comparison"
+	case BinAnd:
+		return "logical and"
+	case BinOr:
+		return "logical or"
 	}
 	return "UNKNOWN"
 }
 
 type BinaryOpStatement struct {
+	NodeInfo
+
 	Op BinaryOp
 }
 
@@ -130,26 +327,179 @@ func (bos *BinaryOpStatement) String() string {
 		tag = "ul"
 	case BinDivide:
 		tag = "div"
+	case BinGreaterThan:
+		tag = "big"
+	case BinLessThan:
+		tag = "small"
+	case BinEqual:
+		tag = "em"
+	case BinAnd:
+		tag = "b"
+	case BinOr:
+		tag = "bdo"
 	default:
 		panic(fmt.Sprintf("Binary operation is not recognized: %v", bos.Op))
 	}
-	return fmt.Sprintf("<%s></%s>", tag, tag)
+	return bos.StartTag(tag) + "</" + tag + ">"
+}
+
+type NotStatement struct {
+	NodeInfo
+}
+
+func (ns *NotStatement) astNode() {}
+func (ns *NotStatement) String() string {
+	return ns.StartTag("bdi") + "</bdi>"
+}
+
+type IfStatement struct {
+	NodeInfo
+
+	Consequence []Node
+	// Alternative is nil if the statement has no else branch.
+	Alternative []Node
+	// SeparatorLayout is the layout of the source code before the <hr> separator.
+	SeparatorLayout Layout
+}
+
+func (is *IfStatement) astNode() {}
+func (is *IfStatement) String() string {
+	consequenceStrings := sliceutil.Map(is.Consequence, func(stmt Node) string { return stmt.String() })
+	if is.Alternative == nil {
+		return is.StartTag("i") + strings.Join(consequenceStrings, "") + "</i>"
+	}
+
+	alternativeStrings := sliceutil.Map(is.Alternative, func(stmt Node) string { return stmt.String() })
+	return is.StartTag("i") + strings.Join(consequenceStrings, "") + "<hr>" +
+		strings.Join(alternativeStrings, "") + "</i>"
+}
+
+type LoopStatement struct {
+	NodeInfo
+
+	Statements []Node
+}
+
+func (ls *LoopStatement) astNode() {}
+func (ls *LoopStatement) String() string {
+	childStrings := sliceutil.Map(ls.Statements, func(stmt Node) string { return stmt.String() })
+	return ls.StartTag("rt") + strings.Join(childStrings, "") + "</rt>"
+}
+
+type BreakStatement struct {
+	NodeInfo
+}
+
+func (bs *BreakStatement) astNode() {}
+func (bs *BreakStatement) String() string {
+	return bs.StartTag("br")
+}
+
+type ContinueStatement struct {
+	NodeInfo
+}
+
+func (cs *ContinueStatement) astNode() {}
+func (cs *ContinueStatement) String() string {
+	return cs.StartTag("rb") + "</rb>"
+}
+
+type JumpStatement struct {
+	NodeInfo
+
+	// Label is the ID of the node to jump to.
+	Label string
+	// Conditional makes the jump only happen if the popped value is true.
+	Conditional bool
+}
+
+func (js *JumpStatement) astNode() {}
+func (js *JumpStatement) String() string {
+	if js.Conditional {
+		return js.StartTag("a", attr("href", "#"+js.Label), "data-if") + "</a>"
+	}
+	return js.StartTag("a", attr("href", "#"+js.Label)) + "</a>"
+}
+
+type GetPropertyStatement struct {
+	NodeInfo
+
+	Property string
+}
+
+func (gps *GetPropertyStatement) astNode() {}
+func (gps *GetPropertyStatement) String() string {
+	return gps.StartTag("rp", attr("title", gps.Property)) + "</rp>"
+}
+
+type SetPropertyStatement struct {
+	NodeInfo
+
+	Property string
+}
+
+func (sps *SetPropertyStatement) astNode() {}
+func (sps *SetPropertyStatement) String() string {
+	return sps.StartTag("samp", attr("title", sps.Property)) + "</samp>"
+}
+
+type GetDynamicPropertyStatement struct {
+	NodeInfo
+}
+
+func (gdps *GetDynamicPropertyStatement) astNode() {}
+func (gdps *GetDynamicPropertyStatement) String() string {
+	return gdps.StartTag("address") + "</address>"
+}
+
+type SetDynamicPropertyStatement struct {
+	NodeInfo
+}
+
+func (sdps *SetDynamicPropertyStatement) astNode() {}
+func (sdps *SetDynamicPropertyStatement) String() string {
+	return sdps.StartTag("ins") + "</ins>"
+}
+
+type FunctionStatement struct {
+	NodeInfo
+
+	Statements []Node
+}
+
+func (fs *FunctionStatement) astNode() {}
+func (fs *FunctionStatement) String() string {
+	childStrings := sliceutil.Map(fs.Statements, func(stmt Node) string { return stmt.String() })
+	return fs.StartTag("dfn") + strings.Join(childStrings, "") + "</dfn>"
+}
+
+type CallStatement struct {
+	NodeInfo
+}
+
+func (cs *CallStatement) astNode() {}
+func (cs *CallStatement) String() string {
+	return cs.StartTag("code") + "</code>"
 }
 
 type GetVariableStatement struct {
+	NodeInfo
+
 	Identifier string
 }
 
 func (gvs *GetVariableStatement) astNode() {}
 func (gvs *GetVariableStatement) String() string {
-	return fmt.Sprintf("<cite>%v</cite>", gvs.Identifier)
+	return gvs.StartTag("cite") + EscapeText(gvs.Identifier) + "</cite>"
 }
 
 type SetVariableStatement struct {
+	NodeInfo
+
 	Identifier string
 }
 
 func (svs *SetVariableStatement) astNode() {}
 func (svs *SetVariableStatement) String() string {
-	return fmt.Sprintf(`<var title="%v"></var>`, svs.Identifier)
+	return svs.StartTag("var", attr("title", svs.Identifier)) + "</var>"
 }
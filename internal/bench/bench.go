@@ -0,0 +1,110 @@
+// Package bench measures how fast programs run.
+package bench
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	"github.com/angelofallars/hypo/internal/runtime"
+)
+
+//go:embed programs/*.html
+var programs embed.FS
+
+// Program is a program to benchmark.
+type Program struct {
+	Name   string
+	Source string
+}
+
+// Programs returns the built-in benchmark programs, which cover common workloads:
+// arithmetic loops, string building, array construction and function calls.
+func Programs() []Program {
+	entries, err := fs.ReadDir(programs, "programs")
+	if err != nil {
+		panic("bench: cannot read embedded programs: " + err.Error())
+	}
+
+	result := []Program{}
+	for _, entry := range entries {
+		source, err := fs.ReadFile(programs, "programs/"+entry.Name())
+		if err != nil {
+			panic("bench: cannot read embedded programs: " + err.Error())
+		}
+		result = append(result, Program{Name: entry.Name(), Source: string(source)})
+	}
+	return result
+}
+
+// Result holds the measurements of running a program several times.
+type Result struct {
+	Runs int
+	// Duration is the total wall time of all runs.
+	Duration time.Duration
+	// Allocs and Bytes are the total number and size of heap allocations.
+	Allocs uint64
+	Bytes  uint64
+	// Statements is the total number of statements executed.
+	Statements int
+}
+
+// TimePerRun returns the average wall time of a run.
+func (r Result) TimePerRun() time.Duration {
+	return r.Duration / time.Duration(r.Runs)
+}
+
+// AllocsPerRun returns the average number of heap allocations of a run.
+func (r Result) AllocsPerRun() uint64 {
+	return r.Allocs / uint64(r.Runs)
+}
+
+// BytesPerRun returns the average number of bytes allocated by a run.
+func (r Result) BytesPerRun() uint64 {
+	return r.Bytes / uint64(r.Runs)
+}
+
+// StatementsPerSecond returns the number of statements executed per second of wall time.
+// It is zero if no time was measured, as for programs faster than the clock's resolution.
+func (r Result) StatementsPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Statements) / r.Duration.Seconds()
+}
+
+// Run parses and runs a program the given number of times, each in a new runtime.
+//
+// Output is discarded and input is empty. Parsing is included in the measurements.
+func Run(src string, runs int, opts ...runtime.Option) (Result, error) {
+	opts = append(opts,
+		runtime.WithStdin(strings.NewReader("")),
+		runtime.WithStdout(io.Discard),
+		runtime.WithStderr(io.Discard),
+	)
+
+	result := Result{Runs: runs}
+
+	var before, after goruntime.MemStats
+	goruntime.ReadMemStats(&before)
+	start := time.Now()
+
+	for i := 0; i < runs; i++ {
+		r := runtime.New(opts...)
+		err := r.Eval(src)
+		result.Statements += r.Env().Statements
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	result.Duration = time.Since(start)
+	goruntime.ReadMemStats(&after)
+	result.Allocs = after.Mallocs - before.Mallocs
+	result.Bytes = after.TotalAlloc - before.TotalAlloc
+
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package bench
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/angelofallars/hypo/internal/runtime"
+)
+
+func TestStatementsPerSecondWithoutDuration(t *testing.T) {
+	result := Result{Runs: 1, Statements: 10}
+	if got := result.StatementsPerSecond(); got != 0 {
+		t.Errorf("StatementsPerSecond() = %v, want 0", got)
+	}
+}
+
+func BenchmarkPrograms(b *testing.B) {
+	for _, program := range Programs() {
+		for _, engine := range []runtime.Engine{runtime.EngineTree, runtime.EngineVM} {
+			program, engine := program, engine
+			b.Run(strings.TrimSuffix(program.Name, ".html")+"/"+string(engine), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					r := runtime.New(
+						runtime.WithEngine(engine),
+						runtime.WithStdin(strings.NewReader("")),
+						runtime.WithStdout(io.Discard),
+						runtime.WithStderr(io.Discard),
+					)
+					if err := r.Eval(program.Source); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/angelofallars/hypo/internal/bench"
+	"github.com/spf13/cobra"
+)
+
+func newBenchCmd() *cobra.Command {
+	var flags runtimeFlags
+	var runs int
+
+	benchCmd := &cobra.Command{
+		Use:   "bench [ file ... ]",
+		Short: "Measure how fast programs run",
+		Long: "Run each program several times and report the average wall time, " +
+			"heap allocations and bytes allocated per run, and the statements executed per second.\n\n" +
+			"Without files, the built-in benchmark programs are run.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if runs < 1 {
+				return fmt.Errorf("number of runs must be at least 1, got %v", runs)
+			}
+
+			opts, err := flags.options(cmd)
+			if err != nil {
+				return err
+			}
+
+			programs := bench.Programs()
+			if len(args) > 0 {
+				programs = []bench.Program{}
+				for _, path := range args {
+					bytes, err := os.ReadFile(path)
+					if err != nil {
+						return err
+					}
+					programs = append(programs, bench.Program{Name: path, Source: string(bytes)})
+				}
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PROGRAM\tRUNS\tTIME/RUN\tALLOCS/RUN\tBYTES/RUN\tSTATEMENTS/S")
+			for _, program := range programs {
+				result, err := bench.Run(program.Source, runs, opts...)
+				if err != nil {
+					w.Flush()
+					printError(os.Stderr, program.Name, program.Source, err)
+					return errReported
+				}
+
+				fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%.0f\n",
+					program.Name, result.Runs, result.TimePerRun(),
+					result.AllocsPerRun(), result.BytesPerRun(), result.StatementsPerSecond())
+			}
+			return w.Flush()
+		},
+	}
+
+	flags.register(benchCmd)
+	benchCmd.Flags().IntVarP(&runs, "runs", "n", 100, "number of times to run each program")
+
+	return benchCmd
+}
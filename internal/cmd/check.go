@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/angelofallars/hypo/internal/analysis"
+	"github.com/angelofallars/hypo/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+func newCheckCmd() *cobra.Command {
+	var config analysis.Config
+
+	checkCmd := &cobra.Command{
+		Use:   "check file ...",
+		Short: "Find problems in programs without running them",
+		Long: "Analyze programs and report statements that always fail when they run, " +
+			"such as stack underflows and reads of variables that are never defined, " +
+			"and warn about values that are pushed but never used.\n\n" +
+			"With --types, also report operations that fail for every type their values can have.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			failed := false
+			for _, path := range args {
+				bytes, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				source := string(bytes)
+
+				program, err := parser.Parse(source)
+				if err != nil {
+					printError(os.Stderr, path, source, err)
+					failed = true
+					continue
+				}
+
+				for _, diagnostic := range analysis.Check(program, config) {
+					printDiagnostic(os.Stderr, path, source, diagnostic)
+					if diagnostic.Severity == analysis.SeverityError {
+						failed = true
+					}
+				}
+			}
+
+			if failed {
+				return errReported
+			}
+			return nil
+		},
+	}
+
+	checkCmd.Flags().BoolVar(&config.Types, "types", false,
+		"report operations on values of the wrong type")
+	checkCmd.Flags().BoolVar(&config.Truthiness, "truthiness", false,
+		"allow any value to be used as a condition, as when running with --truthiness")
+
+	return checkCmd
+}
+
+// printDiagnostic prints a problem found in a file,
+// in the same format as errors that occur while running it.
+func printDiagnostic(w io.Writer, filename string, source string, diagnostic analysis.Diagnostic) {
+	if diagnostic.Pos.IsValid() {
+		fmt.Fprintf(w, "%v:%v\n", filename, diagnostic)
+	} else {
+		fmt.Fprintf(w, "%v: %v\n", filename, diagnostic)
+	}
+
+	lines := strings.Split(source, "\n")
+	if line := diagnostic.Pos.Line; line >= 1 && line <= len(lines) {
+		printExcerpt(w, lines, line, diagnostic.Pos.Column)
+	}
+}
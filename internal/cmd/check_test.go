@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/angelofallars/hypo/internal/analysis"
+	"github.com/angelofallars/hypo/internal/ast"
+)
+
+func TestPrintDiagnostic(t *testing.T) {
+	source := "<s>a</s>\n<output></output>"
+	tests := []struct {
+		name       string
+		diagnostic analysis.Diagnostic
+		want       string
+	}{
+		{
+			name: "with a position",
+			diagnostic: analysis.Diagnostic{
+				Pos:      ast.Position{Line: 1, Column: 1},
+				Severity: analysis.SeverityWarning,
+				Message:  "value pushed by <s>a</s> is never used",
+			},
+			want: "main.html:1:1: warning: value pushed by <s>a</s> is never used\n" +
+				"    1 | <s>a</s>\n" +
+				"      | ^\n",
+		},
+		{
+			name: "without a position",
+			diagnostic: analysis.Diagnostic{
+				Severity: analysis.SeverityWarning,
+				Message:  "value is never used",
+			},
+			want: "main.html: warning: value is never used\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			printDiagnostic(&out, "main.html", source, tt.diagnostic)
+			if got := out.String(); got != tt.want {
+				t.Errorf("got:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
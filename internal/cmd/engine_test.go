@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/angelofallars/hypo/internal/files"
+	"github.com/angelofallars/hypo/internal/golden"
+	"github.com/angelofallars/hypo/internal/runtime"
+)
+
+// testdata is the directory of the test corpus, relative to this package.
+const testdata = "../../testdata"
+
+// TestEngines checks that both engines behave identically on every program in the corpus.
+func TestEngines(t *testing.T) {
+	programs, err := files.Find(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, program := range programs {
+		program := program
+		name, _ := filepath.Rel(testdata, program)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			tree, err := runGolden(program, []runtime.Option{runtime.WithEngine(runtime.EngineTree)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			vm, err := runGolden(program, []runtime.Option{runtime.WithEngine(runtime.EngineVM)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := golden.Diff(tree, vm); diff != "" {
+				t.Errorf("the tree-walking evaluator and the VM differ:\n%v", diff)
+			}
+		})
+	}
+}
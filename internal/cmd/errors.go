@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	errs "github.com/angelofallars/hypo/internal/errors"
+)
+
+// errReported is returned by commands that already printed their errors.
+var errReported = errors.New("error already reported")
+
+// printErrorJSON prints an error that occurred while running a file as JSON,
+// with one object per line for each error.
+func printErrorJSON(w io.Writer, err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			printErrorJSON(w, err)
+		}
+		return
+	}
+
+	encoder := json.NewEncoder(w)
+
+	var hypoErr errs.Error
+	if errors.As(err, &hypoErr) {
+		_ = encoder.Encode(hypoErr)
+		return
+	}
+	_ = encoder.Encode(map[string]string{"message": err.Error()})
+}
+
+// printError prints an error that occurred while running a file.
+//
+// Errors with a position are printed as 'file:line:col: Kind: message',
+// followed by an excerpt of the source code with a caret pointing at the column.
+func printError(w io.Writer, filename string, source string, err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			printError(w, filename, source, err)
+		}
+		return
+	}
+
+	var hypoErr errs.Error
+	if !errors.As(err, &hypoErr) {
+		fmt.Fprintf(w, "%v: %v\n", filename, err)
+		return
+	}
+
+	line, column := hypoErr.Position()
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) {
+		fmt.Fprintf(w, "%v: %v\n", filename, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%v:%v\n", filename, err)
+	printExcerpt(w, lines, line, column)
+}
+
+// printExcerpt prints a line of source code with a caret pointing at a column.
+func printExcerpt(w io.Writer, lines []string, line int, column int) {
+	excerpt := strings.TrimRight(lines[line-1], "\r")
+	gutter := fmt.Sprintf("%5d | ", line)
+
+	// Keep tabs so the caret lines up with the excerpt
+	padding := []rune{}
+	for i, r := range []rune(excerpt) {
+		if i >= column-1 {
+			break
+		}
+		if r == '\t' {
+			padding = append(padding, '\t')
+		} else {
+			padding = append(padding, ' ')
+		}
+	}
+
+	fmt.Fprintf(w, "%v%v\n", gutter, excerpt)
+	fmt.Fprintf(w, "%v| %v^\n", strings.Repeat(" ", len(gutter)-2), string(padding))
+}
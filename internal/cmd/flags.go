@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/angelofallars/hypo/internal/object"
+	"github.com/angelofallars/hypo/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+// runtimeFlags are the flags that configure the runtime,
+// shared by the commands that run programs.
+type runtimeFlags struct {
+	truthiness    bool
+	maxIterations int
+	maxCallDepth  int
+	maxStatements int
+	maxStackDepth int
+	maxVariables  int
+	maxValueSize  int
+	rawOutput     bool
+	engine        string
+	sandbox       bool
+}
+
+// register adds the flags to a command.
+func (f *runtimeFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.truthiness, "truthiness", false,
+		"allow values of any type to be used where a Bool is expected")
+	cmd.Flags().IntVar(&f.maxIterations, "max-iterations", 0,
+		"maximum number of iterations of a single loop, 0 for no limit")
+	cmd.Flags().IntVar(&f.maxCallDepth, "max-call-depth", object.DefaultMaxCallDepth,
+		"maximum number of nested function calls, 0 for no limit")
+	cmd.Flags().IntVar(&f.maxStatements, "max-statements", 0,
+		"maximum number of statements executed, 0 for no limit")
+	cmd.Flags().IntVar(&f.maxStackDepth, "max-stack-depth", 0,
+		"maximum number of values in the stack, 0 for no limit")
+	cmd.Flags().IntVar(&f.maxVariables, "max-variables", 0,
+		"maximum number of variables in a single scope, 0 for no limit")
+	cmd.Flags().IntVar(&f.maxValueSize, "max-value-size", 0,
+		"maximum length of strings in bytes and of arrays and objects in elements, including nested values, 0 for no limit")
+	cmd.Flags().StringVar(&f.engine, "engine", string(runtime.EngineTree),
+		"backend that runs programs, either 'vm' (bytecode) or 'tree' (tree-walking)")
+	cmd.Flags().BoolVar(&f.rawOutput, "raw", false,
+		"print strings without quotes")
+	cmd.Flags().BoolVar(&f.sandbox, "sandbox", false,
+		"only allow the program to write output, denying input, host functions and file access, and ignore breakpoints")
+}
+
+// options returns the runtime options set by the flags of a command.
+func (f *runtimeFlags) options(cmd *cobra.Command) ([]runtime.Option, error) {
+	if f.engine != string(runtime.EngineTree) && f.engine != string(runtime.EngineVM) {
+		return nil, fmt.Errorf("unknown engine '%v', expected 'vm' or 'tree'", f.engine)
+	}
+
+	opts := []runtime.Option{runtime.WithEngine(runtime.Engine(f.engine))}
+	if f.truthiness {
+		opts = append(opts, runtime.WithTruthiness())
+	}
+	if f.maxIterations > 0 {
+		opts = append(opts, runtime.WithMaxIterations(f.maxIterations))
+	}
+	if cmd.Flags().Changed("max-call-depth") {
+		opts = append(opts, runtime.WithMaxCallDepth(f.maxCallDepth))
+	}
+	if f.maxStatements > 0 {
+		opts = append(opts, runtime.WithMaxStatements(f.maxStatements))
+	}
+	if f.maxStackDepth > 0 {
+		opts = append(opts, runtime.WithMaxStackDepth(f.maxStackDepth))
+	}
+	if f.maxVariables > 0 {
+		opts = append(opts, runtime.WithMaxVariables(f.maxVariables))
+	}
+	if f.maxValueSize > 0 {
+		opts = append(opts, runtime.WithMaxValueSize(f.maxValueSize))
+	}
+	if f.rawOutput {
+		opts = append(opts, runtime.WithRawOutput())
+	}
+	if f.sandbox {
+		opts = append(opts, runtime.WithSandbox())
+	}
+	return opts, nil
+}
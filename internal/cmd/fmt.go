@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/angelofallars/hypo/internal/diff"
+	"github.com/angelofallars/hypo/internal/files"
+	"github.com/angelofallars/hypo/internal/format"
+	"github.com/spf13/cobra"
+)
+
+// fmtFlags holds what to do with formatted programs.
+type fmtFlags struct {
+	list  bool
+	write bool
+	diff  bool
+}
+
+func newFmtCmd() *cobra.Command {
+	var flags fmtFlags
+
+	fmtCmd := &cobra.Command{
+		Use:   "fmt [ path ... ]",
+		Short: "Format programs",
+		Long: "Format programs in a canonical style, with nested elements indented and numbers normalized, " +
+			"keeping comments and the line breaks between statements.\n\n" +
+			"Directories are formatted recursively. Without paths, standard input is formatted. " +
+			"By default, the formatted programs are printed to standard output.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				if flags.write {
+					return errors.New("cannot use --write with standard input")
+				}
+				source, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+				return formatFile(cmd.OutOrStdout(), "<standard input>", string(source), flags)
+			}
+
+			paths, err := files.Find(args...)
+			if err != nil {
+				return err
+			}
+
+			failed := false
+			for _, path := range paths {
+				source, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+
+				err = formatFile(cmd.OutOrStdout(), path, string(source), flags)
+				if errors.Is(err, errReported) {
+					failed = true
+				} else if err != nil {
+					return err
+				}
+			}
+
+			if failed {
+				return errReported
+			}
+			return nil
+		},
+	}
+
+	fmtCmd.Flags().BoolVarP(&flags.list, "list", "l", false,
+		"list files whose formatting differs instead of printing them")
+	fmtCmd.Flags().BoolVarP(&flags.write, "write", "w", false,
+		"write the formatted programs to their files instead of printing them")
+	fmtCmd.Flags().BoolVarP(&flags.diff, "diff", "d", false,
+		"print the differences between the programs and their formatting instead of printing them")
+
+	return fmtCmd
+}
+
+// formatFile formats a program and handles the result as the flags say,
+// printing to w.
+func formatFile(w io.Writer, path string, source string, flags fmtFlags) error {
+	formatted, err := format.Source(source)
+	if err != nil {
+		printError(os.Stderr, path, source, err)
+		return errReported
+	}
+
+	if !flags.list && !flags.write && !flags.diff {
+		fmt.Fprint(w, formatted)
+		return nil
+	}
+	if formatted == source {
+		return nil
+	}
+
+	if flags.list {
+		fmt.Fprintln(w, path)
+	}
+	if flags.write {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(formatted), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if flags.diff {
+		fmt.Fprint(w, diff.Unified(path+".orig", path, source, formatted))
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	formattedProgram   = "<s>a</s><output></output>\n"
+	unformattedProgram = `<data value="1.0"></data><output></output>`
+	// reformattedProgram is unformattedProgram after formatting.
+	reformattedProgram = "<data value=\"1\"></data><output></output>\n"
+)
+
+// runFmt runs hypo fmt with arguments and standard input,
+// returning what it printed to standard output.
+func runFmt(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var out strings.Builder
+	fmtCmd := newFmtCmd()
+	fmtCmd.SetArgs(args)
+	fmtCmd.SetIn(strings.NewReader(stdin))
+	fmtCmd.SetOut(&out)
+	fmtCmd.SetErr(io.Discard)
+	err := fmtCmd.Execute()
+	return out.String(), err
+}
+
+// writeFiles writes a formatted and an unformatted program to a temporary directory,
+// returning their paths.
+func writeFiles(t *testing.T) (formatted, unformatted string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	formatted = filepath.Join(dir, "formatted.html")
+	unformatted = filepath.Join(dir, "unformatted.html")
+	if err := os.WriteFile(formatted, []byte(formattedProgram), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(unformatted, []byte(unformattedProgram), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return formatted, unformatted
+}
+
+// readFile returns the contents of a file.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bytes)
+}
+
+func TestFmtStdin(t *testing.T) {
+	out, err := runFmt(t, unformattedProgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != reformattedProgram {
+		t.Errorf("got %q, want %q", out, reformattedProgram)
+	}
+}
+
+func TestFmtFiles(t *testing.T) {
+	formatted, unformatted := writeFiles(t)
+
+	out, err := runFmt(t, "", filepath.Dir(formatted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := formattedProgram + reformattedProgram; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if got := readFile(t, unformatted); got != unformattedProgram {
+		t.Errorf("printing the formatted program changed its file to %q", got)
+	}
+}
+
+func TestFmtList(t *testing.T) {
+	formatted, unformatted := writeFiles(t)
+
+	out, err := runFmt(t, "", "-l", formatted, unformatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unformatted + "\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if got := readFile(t, unformatted); got != unformattedProgram {
+		t.Errorf("listing the program changed its file to %q", got)
+	}
+}
+
+func TestFmtWrite(t *testing.T) {
+	formatted, unformatted := writeFiles(t)
+
+	out, err := runFmt(t, "", "-w", formatted, unformatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("got output %q, want none", out)
+	}
+	if got := readFile(t, formatted); got != formattedProgram {
+		t.Errorf("formatted file was changed to %q", got)
+	}
+	if got := readFile(t, unformatted); got != reformattedProgram {
+		t.Errorf("got %q, want %q", got, reformattedProgram)
+	}
+}
+
+func TestFmtWriteStdin(t *testing.T) {
+	if _, err := runFmt(t, unformattedProgram, "-w"); err == nil {
+		t.Error("formatting standard input with -w succeeded")
+	}
+}
+
+func TestFmtDiff(t *testing.T) {
+	formatted, unformatted := writeFiles(t)
+
+	out, err := runFmt(t, "", "-d", formatted, unformatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "diff " + unformatted + ".orig " + unformatted + "\n" +
+		"--- " + unformatted + ".orig\n" +
+		"+++ " + unformatted + "\n" +
+		"@@ -1,1 +1,1 @@\n" +
+		"-" + unformattedProgram + "\n" +
+		"\\ No newline at end of file\n" +
+		"+" + reformattedProgram
+	if out != want {
+		t.Errorf("got:\n%v\nwant:\n%v", out, want)
+	}
+	if got := readFile(t, unformatted); got != unformattedProgram {
+		t.Errorf("diffing the program changed its file to %q", got)
+	}
+}
+
+func TestFmtParseError(t *testing.T) {
+	_, unformatted := writeFiles(t)
+	invalid := filepath.Join(filepath.Dir(unformatted), "invalid.html")
+	if err := os.WriteFile(invalid, []byte("<blink></blink>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The other files are still formatted
+	_, err := runFmt(t, "", "-w", invalid, unformatted)
+	if !errors.Is(err, errReported) {
+		t.Errorf("got error %v, want the parse error to be reported", err)
+	}
+	if got := readFile(t, unformatted); got != reformattedProgram {
+		t.Errorf("got %q, want %q", got, reformattedProgram)
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/angelofallars/hypo/internal/files"
+	"github.com/angelofallars/hypo/internal/golden"
+)
+
+var update = flag.Bool("update", false, "write the actual behavior of programs to their golden files")
+
+// TestGolden checks that every program in the corpus behaves as recorded in its golden file.
+func TestGolden(t *testing.T) {
+	programs, err := files.Find(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, program := range programs {
+		program := program
+		name, _ := filepath.Rel(testdata, program)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			got, err := runGolden(program, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			goldenPath := golden.Path(program)
+			if *update {
+				if err := os.WriteFile(goldenPath, got.Marshal(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			diff, err := diffGolden(goldenPath, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff != "" {
+				t.Errorf("behavior differs from %v:\n%v", goldenPath, diff)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/angelofallars/hypo/internal/repl"
 	"github.com/angelofallars/hypo/internal/runtime"
@@ -9,14 +13,39 @@ import (
 )
 
 func Exec() int {
+	var flags runtimeFlags
+	var noBreakpoints bool
+	var errorFormat string
+	var timeout time.Duration
+
 	rootCmd := &cobra.Command{
-		Use:          "hypo [ file ]",
-		Short:        "Hypo is a fast runtime for HTML, the programming language running outside the browser.",
-		Args:         cobra.MaximumNArgs(1),
-		SilenceUsage: true,
+		Use:           "hypo [ file ]",
+		Short:         "Hypo is a fast runtime for HTML, the programming language running outside the browser.",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if errorFormat != "text" && errorFormat != "json" {
+				return fmt.Errorf("unknown error format '%v', expected 'text' or 'json'", errorFormat)
+			}
+
+			opts, err := flags.options(cmd)
+			if err != nil {
+				return err
+			}
+			// Breakpoints need a terminal to read commands from,
+			// and are ignored in the sandbox
+			if !noBreakpoints && !flags.sandbox {
+				if terminal := openTerminal(); terminal != nil {
+					if terminal != os.Stdin {
+						defer terminal.Close()
+					}
+					opts = append(opts, runtime.WithDebugger(repl.NewDebugger(terminal, os.Stderr)))
+				}
+			}
+
 			if len(args) == 0 {
-				repl.Start()
+				repl.Start(opts...)
 				return nil
 			}
 
@@ -27,18 +56,58 @@ func Exec() int {
 
 			contents := string(bytes)
 
-			err = runtime.New().Eval(contents)
+			ctx := context.Background()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			err = runtime.New(opts...).EvalContext(ctx, contents)
 			if err != nil {
-				return err
+				if errorFormat == "json" {
+					printErrorJSON(os.Stderr, err)
+				} else {
+					printError(os.Stderr, args[0], contents, err)
+				}
+				return errReported
 			}
 
 			return nil
 		},
 	}
 
+	flags.register(rootCmd)
+	rootCmd.Flags().BoolVar(&noBreakpoints, "no-breakpoints", false,
+		"ignore <wbr> breakpoints instead of pausing execution")
+	rootCmd.Flags().StringVar(&errorFormat, "error-format", "text",
+		"format of errors printed to stderr, either 'text' or 'json'")
+	rootCmd.Flags().DurationVar(&timeout, "timeout", 0,
+		"stop the program after a duration such as '5s', 0 for no limit")
+
+	rootCmd.AddCommand(newBenchCmd(), newTestCmd(), newCheckCmd(), newFmtCmd())
+
 	if err := rootCmd.Execute(); err != nil {
+		if !errors.Is(err, errReported) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		return 1
 	}
 
 	return 0
 }
+
+// openTerminal opens the controlling terminal to read debugger commands from,
+// so that the debugger does not read the input of the program when it is piped.
+// It returns nil if there is no terminal.
+func openTerminal() *os.File {
+	if terminal, err := os.Open("/dev/tty"); err == nil {
+		return terminal
+	}
+
+	// Systems without /dev/tty can still use stdin if it is a terminal
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return os.Stdin
+	}
+	return nil
+}
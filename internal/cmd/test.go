@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/angelofallars/hypo/internal/files"
+	"github.com/angelofallars/hypo/internal/golden"
+	"github.com/angelofallars/hypo/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+func newTestCmd() *cobra.Command {
+	var flags runtimeFlags
+	var update bool
+
+	testCmd := &cobra.Command{
+		Use:   "test [ path ... ]",
+		Short: "Check that programs behave as recorded in their golden files",
+		Long: "Run every .html file in the given files and directories, and compare its " +
+			"standard output, standard error and exit code to its .golden file.\n\n" +
+			"If a .stdin file exists next to a program, it is used as the program's standard input, " +
+			"and if a .flags file exists, the program is run with the runtime flags it holds. " +
+			"Without paths, the testdata directory is used. " +
+			"Pass --update (or -u) to write the actual behavior to the golden files instead.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.options(cmd)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				args = []string{"testdata"}
+			}
+			programs, err := files.Find(args...)
+			if err != nil {
+				return err
+			}
+
+			passed, failed := 0, 0
+			for _, program := range programs {
+				got, err := runGolden(program, opts)
+				if err != nil {
+					return err
+				}
+
+				goldenPath := golden.Path(program)
+				if update {
+					if err := os.WriteFile(goldenPath, got.Marshal(), 0o644); err != nil {
+						return err
+					}
+					fmt.Printf("updated %v\n", goldenPath)
+					continue
+				}
+
+				diff, err := diffGolden(goldenPath, got)
+				if err != nil {
+					return err
+				}
+				if diff != "" {
+					failed++
+					fmt.Printf("FAIL %v\n%v\n", program, indent(diff))
+					continue
+				}
+				passed++
+				fmt.Printf("ok   %v\n", program)
+			}
+
+			if update {
+				return nil
+			}
+			fmt.Printf("%v passed, %v failed\n", passed, failed)
+			if failed > 0 {
+				return errReported
+			}
+			return nil
+		},
+	}
+
+	flags.register(testCmd)
+	testCmd.Flags().BoolVarP(&update, "update", "u", false,
+		"write the actual behavior of programs to their golden files")
+
+	return testCmd
+}
+
+// runGolden runs a program the way the root command does,
+// and records its output and exit code.
+//
+// The flags of the program are applied before the given options,
+// so the given options take precedence.
+func runGolden(program string, opts []runtime.Option) (golden.Result, error) {
+	source, err := os.ReadFile(program)
+	if err != nil {
+		return golden.Result{}, err
+	}
+
+	programOpts, err := programOptions(program)
+	if err != nil {
+		return golden.Result{}, err
+	}
+	opts = append(programOpts, opts...)
+
+	stdin, err := os.ReadFile(golden.StdinPath(program))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return golden.Result{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	opts = append(opts,
+		runtime.WithStdin(bytes.NewReader(stdin)),
+		runtime.WithStdout(&stdout),
+		runtime.WithStderr(&stderr),
+	)
+
+	exitCode := 0
+	err = runtime.New(opts...).Eval(string(source))
+	if err != nil {
+		// Only the file name is printed so golden files do not depend on the working directory
+		printError(&stderr, filepath.Base(program), string(source), err)
+		exitCode = 1
+	}
+
+	return golden.Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitCode}, nil
+}
+
+// programOptions returns the runtime options set by the flags file of a program.
+func programOptions(program string) ([]runtime.Option, error) {
+	data, err := os.ReadFile(golden.FlagsPath(program))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var flags runtimeFlags
+	cmd := &cobra.Command{}
+	flags.register(cmd)
+	if err := cmd.Flags().Parse(strings.Fields(string(data))); err != nil {
+		return nil, fmt.Errorf("%v: %w", golden.FlagsPath(program), err)
+	}
+	return flags.options(cmd)
+}
+
+// diffGolden compares a result to the contents of a golden file.
+func diffGolden(goldenPath string, got golden.Result) (string, error) {
+	data, err := os.ReadFile(goldenPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "no golden file, run with --update to create it", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	want, err := golden.Parse(data)
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", goldenPath, err)
+	}
+	return golden.Diff(want, got), nil
+}
+
+// indent indents every line of text.
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(text, "\n", "\n    ")
+}
@@ -0,0 +1,254 @@
+// Package diff compares texts line by line.
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// edit is a line that is kept, removed or added.
+type edit struct {
+	kind byte
+	line string
+	// oldLine and newLine are the indexes of the line in each text,
+	// or of the next line for lines that are not in that text.
+	oldLine int
+	newLine int
+}
+
+// Unified returns the differences between two texts in the unified diff format,
+// or an empty string if they are equal.
+func Unified(oldName, newName, old, new string) string {
+	if old == new {
+		return ""
+	}
+
+	edits := compare(splitLines(old), splitLines(new))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff %v %v\n--- %v\n+++ %v\n", oldName, newName, oldName, newName)
+	for i := 0; i < len(edits); {
+		if edits[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Changes separated by a few unchanged lines share a hunk
+		start, end := max(i-context, 0), i
+		for end < len(edits) {
+			if edits[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next].kind == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		end = min(end+context, len(edits))
+
+		writeHunk(&b, edits[start:end])
+		i = end
+	}
+	return b.String()
+}
+
+// splitLines splits a text into lines that keep their line breaks.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// compare finds the edits that turn one list of lines into another,
+// with as few removed and added lines as possible.
+//
+// Lines are compared with Myers' algorithm in linear space, so memory does not
+// grow with the product of the lengths of the texts. Within a change, removed
+// lines come before added lines.
+func compare(old, new []string) []edit {
+	d := &differ{old: old, new: new}
+	d.diff(0, len(old), 0, len(new))
+
+	// Sort the lines of each change, then number every line
+	edits := d.edits
+	for start := 0; start < len(edits); start++ {
+		end := start
+		for end < len(edits) && edits[end].kind != ' ' {
+			end++
+		}
+		slices.SortStableFunc(edits[start:end], func(a, b edit) int {
+			return cmp.Compare(b.kind, a.kind)
+		})
+		start = end
+	}
+
+	i, j := 0, 0
+	for k := range edits {
+		edits[k].oldLine, edits[k].newLine = i, j
+		if edits[k].kind != '+' {
+			i++
+		}
+		if edits[k].kind != '-' {
+			j++
+		}
+	}
+	return edits
+}
+
+// differ holds the state of a comparison.
+type differ struct {
+	old, new []string
+	edits    []edit
+}
+
+// diff appends the edits that turn old[oldStart:oldEnd] into new[newStart:newEnd].
+func (d *differ) diff(oldStart, oldEnd, newStart, newEnd int) {
+	// Lines at the start and end that are the same in both ranges are kept
+	prefix := 0
+	for oldStart+prefix < oldEnd && newStart+prefix < newEnd &&
+		d.old[oldStart+prefix] == d.new[newStart+prefix] {
+		prefix++
+	}
+	suffix := 0
+	for oldStart+prefix < oldEnd-suffix && newStart+prefix < newEnd-suffix &&
+		d.old[oldEnd-1-suffix] == d.new[newEnd-1-suffix] {
+		suffix++
+	}
+
+	d.keep(oldStart, oldStart+prefix)
+	oldStart, newStart = oldStart+prefix, newStart+prefix
+	oldEnd, newEnd = oldEnd-suffix, newEnd-suffix
+
+	switch {
+	case oldStart == oldEnd:
+		for j := newStart; j < newEnd; j++ {
+			d.edits = append(d.edits, edit{kind: '+', line: d.new[j]})
+		}
+	case newStart == newEnd:
+		for i := oldStart; i < oldEnd; i++ {
+			d.edits = append(d.edits, edit{kind: '-', line: d.old[i]})
+		}
+	default:
+		// Both ranges start and end with different lines, so the shortest
+		// edit script has at least two edits, and splitting it at the middle
+		// snake leaves shorter scripts on each side
+		x, y, u, v := d.middleSnake(oldStart, oldEnd, newStart, newEnd)
+		d.diff(oldStart, x, newStart, y)
+		d.keep(x, u)
+		d.diff(u, oldEnd, v, newEnd)
+	}
+
+	d.keep(oldEnd, oldEnd+suffix)
+}
+
+// keep appends the old lines in a range as unchanged lines.
+func (d *differ) keep(start, end int) {
+	for i := start; i < end; i++ {
+		d.edits = append(d.edits, edit{kind: ' ', line: d.old[i]})
+	}
+}
+
+// middleSnake finds the middle snake of the shortest edit script that turns
+// old[oldStart:oldEnd] into new[newStart:newEnd]: a run of equal lines from
+// old[x:u] and new[y:v] that the script passes through halfway.
+//
+// The script is searched for from both ends at once, following
+// "An O(ND) Difference Algorithm and Its Variations" by Eugene W. Myers.
+func (d *differ) middleSnake(oldStart, oldEnd, newStart, newEnd int) (x, y, u, v int) {
+	n, m := oldEnd-oldStart, newEnd-newStart
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+
+	// forward[offset+k] is how far along old the furthest path forward on
+	// diagonal k reaches, and backward[offset+k] how far the furthest path
+	// backward reaches from the end, on the diagonal k of the reversed ranges
+	offset := limit + 1
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for depth := 0; depth <= limit; depth++ {
+		for k := -depth; k <= depth; k += 2 {
+			x := forward[offset+k-1] + 1
+			if k == -depth || (k != depth && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.old[oldStart+x] == d.new[newStart+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			reversed := delta - k
+			if odd && reversed >= -(depth-1) && reversed <= depth-1 && x+backward[offset+reversed] >= n {
+				return oldStart + startX, newStart + startY, oldStart + x, newStart + y
+			}
+		}
+
+		for k := -depth; k <= depth; k += 2 {
+			x := backward[offset+k-1] + 1
+			if k == -depth || (k != depth && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.old[oldEnd-1-x] == d.new[newEnd-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			reversed := delta - k
+			if !odd && reversed >= -depth && reversed <= depth && x+forward[offset+reversed] >= n {
+				return oldEnd - x, newEnd - y, oldEnd - startX, newEnd - startY
+			}
+		}
+	}
+
+	panic("diff: no middle snake found")
+}
+
+// writeHunk writes a hunk of edits with its header.
+func writeHunk(b *strings.Builder, edits []edit) {
+	oldCount, newCount := 0, 0
+	for _, e := range edits {
+		if e.kind != '+' {
+			oldCount++
+		}
+		if e.kind != '-' {
+			newCount++
+		}
+	}
+
+	// Empty ranges start at the line before them
+	oldStart, newStart := edits[0].oldLine, edits[0].newLine
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+	fmt.Fprintf(b, "@@ -%v,%v +%v,%v @@\n", oldStart, oldCount, newStart, newCount)
+
+	for _, e := range edits {
+		b.WriteByte(e.kind)
+		b.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	old := "a\nb\nc\nd\n"
+	new := "a\nx\nc\nd\ne\n"
+	want := `diff old new
+--- old
++++ new
+@@ -1,4 +1,5 @@
+ a
+-b
++x
+ c
+ d
++e
+`
+	if got := Unified("old", "new", old, new); got != want {
+		t.Errorf("Unified() =\n%v\nwant\n%v", got, want)
+	}
+	if got := Unified("old", "new", old, old); got != "" {
+		t.Errorf("Unified() of equal texts = %q, want an empty string", got)
+	}
+}
+
+// TestCompareShortest checks that compare turns one text into the other
+// with as few edits as the longest common subsequence allows.
+func TestCompareShortest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	lines := func() []string {
+		result := make([]string, random.Intn(12))
+		for i := range result {
+			result[i] = string(rune('a' + random.Intn(3)))
+		}
+		return result
+	}
+
+	for i := 0; i < 2000; i++ {
+		old, new := lines(), lines()
+		edits := compare(old, new)
+
+		var gotOld, gotNew []string
+		changes := 0
+		for _, e := range edits {
+			if e.kind != '+' {
+				gotOld = append(gotOld, e.line)
+			}
+			if e.kind != '-' {
+				gotNew = append(gotNew, e.line)
+			}
+			if e.kind != ' ' {
+				changes++
+			}
+		}
+
+		if strings.Join(gotOld, "") != strings.Join(old, "") || strings.Join(gotNew, "") != strings.Join(new, "") {
+			t.Fatalf("compare(%q, %q) = %v does not turn one into the other", old, new, edits)
+		}
+		if want := len(old) + len(new) - 2*commonLength(old, new); changes != want {
+			t.Fatalf("compare(%q, %q) has %v changes, want %v", old, new, changes, want)
+		}
+	}
+}
+
+// commonLength returns the length of the longest common subsequence of two lists of lines.
+func commonLength(old, new []string) int {
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	return common[0][0]
+}
+
+func BenchmarkUnifiedLarge(b *testing.B) {
+	var old, new strings.Builder
+	for i := 0; i < 100000; i++ {
+		line := strings.Repeat("x", i%7) + "\n"
+		old.WriteString(line)
+		if i%1000 == 0 {
+			new.WriteString("changed\n")
+		} else {
+			new.WriteString(line)
+		}
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Unified("old", "new", old.String(), new.String())
+	}
+}
@@ -1,7 +1,10 @@
 // package errs provides error types that occur in the runtime.
 package errs
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type ErrorKind string
 
@@ -11,20 +14,204 @@ const (
 	VariableKind  ErrorKind = "VariableError"
 	TypeKind      ErrorKind = "TypeError"
 	AttributeKind ErrorKind = "AttributeError"
+	LoopKind      ErrorKind = "LoopError"
+	RecursionKind ErrorKind = "RecursionError"
+	IndexKind     ErrorKind = "IndexError"
+	AbortKind     ErrorKind = "AbortError"
+	IOKind        ErrorKind = "IOError"
+	HostKind      ErrorKind = "HostError"
+
+	CancelKind         ErrorKind = "CancelError"
+	StatementLimitKind ErrorKind = "StatementLimitError"
+	StackOverflowKind  ErrorKind = "StackOverflowError"
+	VariableLimitKind  ErrorKind = "VariableLimitError"
+	SizeLimitKind      ErrorKind = "SizeLimitError"
+	PermissionKind     ErrorKind = "PermissionError"
 )
 
 // Dummy method
 func (ek ErrorKind) errorKind() {}
 
+// Code returns the stable numeric code of an error kind.
+// Codes are never reused or changed once assigned:
+//
+//	1  ParseError
+//	2  StackError
+//	3  VariableError
+//	4  TypeError
+//	5  AttributeError
+//	6  LoopError
+//	7  RecursionError
+//	8  IndexError
+//	9  AbortError
+//	10 IOError
+//	11 HostError
+//	12 CancelError
+//	13 StatementLimitError
+//	14 StackOverflowError
+//	15 VariableLimitError
+//	16 SizeLimitError
+//	17 PermissionError
+//
+// Unknown kinds have the code 0.
+func (ek ErrorKind) Code() int {
+	switch ek {
+	case ParseKind:
+		return 1
+	case StackKind:
+		return 2
+	case VariableKind:
+		return 3
+	case TypeKind:
+		return 4
+	case AttributeKind:
+		return 5
+	case LoopKind:
+		return 6
+	case RecursionKind:
+		return 7
+	case IndexKind:
+		return 8
+	case AbortKind:
+		return 9
+	case IOKind:
+		return 10
+	case HostKind:
+		return 11
+	case CancelKind:
+		return 12
+	case StatementLimitKind:
+		return 13
+	case StackOverflowKind:
+		return 14
+	case VariableLimitKind:
+		return 15
+	case SizeLimitKind:
+		return 16
+	case PermissionKind:
+		return 17
+	}
+	return 0
+}
+
+// Sentinel errors for use with [errors.Is]. An [Error] matches the sentinel of its kind.
+var (
+	ErrParse             = newSentinel(ParseKind)
+	ErrStackUnderflow    = newSentinel(StackKind)
+	ErrUndefinedVariable = newSentinel(VariableKind)
+	ErrType              = newSentinel(TypeKind)
+	ErrAttribute         = newSentinel(AttributeKind)
+	ErrLoopLimit         = newSentinel(LoopKind)
+	ErrRecursionLimit    = newSentinel(RecursionKind)
+	ErrIndex             = newSentinel(IndexKind)
+	ErrAborted           = newSentinel(AbortKind)
+	ErrIO                = newSentinel(IOKind)
+	ErrHost              = newSentinel(HostKind)
+	ErrCanceled          = newSentinel(CancelKind)
+	ErrStatementLimit    = newSentinel(StatementLimitKind)
+	ErrStackOverflow     = newSentinel(StackOverflowKind)
+	ErrVariableLimit     = newSentinel(VariableLimitKind)
+	ErrSizeLimit         = newSentinel(SizeLimitKind)
+	ErrPermission        = newSentinel(PermissionKind)
+)
+
 type Error struct {
 	message string
 	kind    ErrorKind
+	// line and column are where the error occurred in the source code,
+	// starting from 1. They are zero if the position is unknown.
+	line   int
+	column int
+	// cause is the underlying error, if any.
+	cause error
+	// sentinel marks errors that only exist to be compared against.
+	sentinel bool
+}
+
+func newSentinel(kind ErrorKind) Error {
+	return Error{
+		message:  "any " + string(kind),
+		kind:     kind,
+		sentinel: true,
+	}
+}
+
+// Kind returns the kind of the error.
+func (e Error) Kind() ErrorKind { return e.kind }
+
+// Code returns the stable numeric code of the error's kind.
+func (e Error) Code() int { return e.kind.Code() }
+
+// Message returns the error message without the kind and position.
+func (e Error) Message() string { return e.message }
+
+// Unwrap returns the underlying cause of the error, if any.
+func (e Error) Unwrap() error { return e.cause }
+
+// Is reports whether the error matches a sentinel error of the same kind.
+func (e Error) Is(target error) bool {
+	t, ok := target.(Error)
+	return ok && t.sentinel && t.kind == e.kind
+}
+
+// Wrap returns a copy of the error with an underlying cause.
+func (e Error) Wrap(cause error) Error {
+	e.cause = cause
+	return e
+}
+
+// MarshalJSON renders the error as a JSON object with its kind, code,
+// message, position and cause. The position and cause are omitted if unknown.
+func (e Error) MarshalJSON() ([]byte, error) {
+	type jsonError struct {
+		Kind    ErrorKind `json:"kind"`
+		Code    int       `json:"code"`
+		Message string    `json:"message"`
+		Line    int       `json:"line,omitempty"`
+		Column  int       `json:"column,omitempty"`
+		Cause   string    `json:"cause,omitempty"`
+	}
+
+	rendered := jsonError{
+		Kind:    e.kind,
+		Code:    e.Code(),
+		Message: e.message,
+		Line:    e.line,
+		Column:  e.column,
+	}
+	if e.cause != nil {
+		rendered.Cause = e.cause.Error()
+	}
+
+	return json.Marshal(rendered)
 }
 
 func (e Error) Error() string {
+	if e.line > 0 {
+		return fmt.Sprintf("%v:%v: %v: %v", e.line, e.column, e.kind, e.message)
+	}
 	return fmt.Sprintf("%v: %v", e.kind, e.message)
 }
 
+// Position returns the line and column where the error occurred.
+// Both are zero if the position is unknown.
+func (e Error) Position() (line int, column int) {
+	return e.line, e.column
+}
+
+// At attaches a position in the source code to an [Error].
+//
+// Other errors, and errors which already have a position, are returned unchanged.
+func At(err error, line int, column int) error {
+	e, ok := err.(Error)
+	if !ok || e.line > 0 || line <= 0 {
+		return err
+	}
+
+	e.line, e.column = line, column
+	return e
+}
+
 func newHypoError(kind ErrorKind, message string, format []any) Error {
 	return Error{
 		message: fmt.Sprintf(message, format...),
@@ -56,3 +243,63 @@ func NewTypeError(message string, format ...any) Error {
 func NewAttributeError(message string, format ...any) Error {
 	return newHypoError(AttributeKind, message, format)
 }
+
+// NewLoopError returns a loop error with a message.
+func NewLoopError(message string, format ...any) Error {
+	return newHypoError(LoopKind, message, format)
+}
+
+// NewRecursionError returns a recursion error with a message.
+func NewRecursionError(message string, format ...any) Error {
+	return newHypoError(RecursionKind, message, format)
+}
+
+// NewIndexError returns an index error with a message.
+func NewIndexError(message string, format ...any) Error {
+	return newHypoError(IndexKind, message, format)
+}
+
+// NewAbortError returns an abort error with a message.
+func NewAbortError(message string, format ...any) Error {
+	return newHypoError(AbortKind, message, format)
+}
+
+// NewIOError returns an I/O error with a message.
+func NewIOError(message string, format ...any) Error {
+	return newHypoError(IOKind, message, format)
+}
+
+// NewHostError returns a host function error with a message.
+func NewHostError(message string, format ...any) Error {
+	return newHypoError(HostKind, message, format)
+}
+
+// NewCancelError returns an error for a canceled execution with a message.
+func NewCancelError(message string, format ...any) Error {
+	return newHypoError(CancelKind, message, format)
+}
+
+// NewStatementLimitError returns a statement limit error with a message.
+func NewStatementLimitError(message string, format ...any) Error {
+	return newHypoError(StatementLimitKind, message, format)
+}
+
+// NewStackOverflowError returns a stack overflow error with a message.
+func NewStackOverflowError(message string, format ...any) Error {
+	return newHypoError(StackOverflowKind, message, format)
+}
+
+// NewVariableLimitError returns a variable limit error with a message.
+func NewVariableLimitError(message string, format ...any) Error {
+	return newHypoError(VariableLimitKind, message, format)
+}
+
+// NewSizeLimitError returns a size limit error with a message.
+func NewSizeLimitError(message string, format ...any) Error {
+	return newHypoError(SizeLimitKind, message, format)
+}
+
+// NewPermissionError returns an error for an operation the program is not allowed to perform.
+func NewPermissionError(message string, format ...any) Error {
+	return newHypoError(PermissionKind, message, format)
+}
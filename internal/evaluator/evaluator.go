@@ -1,13 +1,43 @@
 package evaluator
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/angelofallars/hypo/internal/ast"
 	errs "github.com/angelofallars/hypo/internal/errors"
 	"github.com/angelofallars/hypo/internal/object"
 )
 
+// controlFlow is a signal that unwinds evaluation up to the enclosing loop.
+//
+// Signals are propagated as errors through [Exec].
+type controlFlow string
+
+func (cf controlFlow) Error() string { return string(cf) + " outside of loop" }
+
+const (
+	errBreak    controlFlow = "break"
+	errContinue controlFlow = "continue"
+)
+
+// jump is a signal that unwinds evaluation up to the block
+// containing the statement with the label.
+//
+// Signals are propagated as errors through [Exec].
+type jump struct {
+	label string
+}
+
+func (j jump) Error() string { return fmt.Sprintf("label '%v' is not defined", j.label) }
+
 // Exec evaluates a single [ast.Node].
 func Exec(node ast.Node, env *object.Env) error {
 	var err error
@@ -28,8 +58,8 @@ func Exec(node ast.Node, env *object.Env) error {
 		err = evalPushNumber(node, env)
 	case *ast.ArrayStatement:
 		err = evalPushArray(node, env)
-	// case *ast.TableStatement:
-	// 	err = evalPushTable(node, env)
+	case *ast.TableStatement:
+		err = evalPushTable(node, env)
 
 	// ===============================
 	// Math commands
@@ -37,6 +67,12 @@ func Exec(node ast.Node, env *object.Env) error {
 	case *ast.BinaryOpStatement:
 		err = evalBinOp(node, env)
 
+	// ===============================
+	// Logical operators
+	// ===============================
+	case *ast.NotStatement:
+		err = evalNot(node, env)
+
 	// ===============================
 	// Stack Manipulation Commands
 	// ===============================
@@ -45,6 +81,20 @@ func Exec(node ast.Node, env *object.Env) error {
 	case *ast.DeleteStatement:
 		err = evalDelete(node, env)
 
+	// ===============================
+	// Control flow
+	// ===============================
+	case *ast.IfStatement:
+		err = evalIf(node, env)
+	case *ast.LoopStatement:
+		err = evalLoop(node, env)
+	case *ast.BreakStatement:
+		err = errBreak
+	case *ast.ContinueStatement:
+		err = errContinue
+	case *ast.JumpStatement:
+		err = evalJump(node, env)
+
 	// ===============================
 	// Variables
 	// ===============================
@@ -53,11 +103,40 @@ func Exec(node ast.Node, env *object.Env) error {
 	case *ast.GetVariableStatement:
 		err = evalGetVariable(node, env)
 
+	// ===============================
+	// Properties
+	// ===============================
+	case *ast.GetPropertyStatement:
+		err = evalGetProperty(node, env)
+	case *ast.SetPropertyStatement:
+		err = evalSetProperty(node, env)
+	case *ast.GetDynamicPropertyStatement:
+		err = evalGetDynamicProperty(node, env)
+	case *ast.SetDynamicPropertyStatement:
+		err = evalSetDynamicProperty(node, env)
+
+	// ===============================
+	// Functions
+	// ===============================
+	case *ast.FunctionStatement:
+		err = evalPushFunction(node, env)
+	case *ast.CallStatement:
+		err = evalCall(node, env)
+
 	// ===============================
 	// I/O
 	// ===============================
 	case *ast.PrintStatement:
 		err = evalPrint(node, env)
+	case *ast.InputStatement:
+		err = evalInput(node, env)
+	case *ast.BreakpointStatement:
+		err = evalBreakpoint(node, env)
+	}
+
+	if err != nil {
+		pos := node.Info().Pos
+		err = errs.At(err, pos.Line, pos.Column)
 	}
 
 	return err
@@ -65,13 +144,7 @@ func Exec(node ast.Node, env *object.Env) error {
 
 // evalProgram evaluates an [ast.Program].
 func evalProgram(program *ast.Program, env *object.Env) error {
-	for _, statement := range program.Statements {
-		err := Exec(statement, env)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return evalBlock(program.Statements, env)
 }
 
 // evalPushString pushes a string into the stack.
@@ -83,30 +156,32 @@ func evalPushString(node *ast.StringStatement, env *object.Env) error {
 
 // evalPushNumber pushes a number into the stack.
 func evalPushNumber(node *ast.NumberStatement, env *object.Env) error {
-	object := &object.Number{Value: node.Value}
+	object := object.NewNumber(node.Value)
 	env.Stack.Push(object)
 	return nil
 }
 
 // evalPushArray pushes an array into the stack.
-func evalPushArray(node *ast.ArrayStatement, env *object.Env) error {
+func evalPushArray(node *ast.ArrayStatement, env *object.Env) (err error) {
 	obj := &object.Array{}
 
 	elements := []object.Object{}
 
 	initialLength := env.Stack.Len()
 	defer func() {
-		// Pop any excess objects
-		removeCount := env.Stack.Len() - initialLength - 1
-		_, _ = env.Stack.PopMany(removeCount)
+		// Pop any excess objects, including the partly built literal
+		// if an error or a <br>, <rb> or <a> left it
+		keep := initialLength + 1
+		if err != nil {
+			keep = initialLength
+		}
+		_ = env.Stack.Drop(env.Stack.Len() - keep)
 	}()
 
 	for _, childNode := range node.Elements {
-		for _, childChildNode := range childNode.Statements {
-			err := Exec(childChildNode, env)
-			if err != nil {
-				return err
-			}
+		err := evalBlock(childNode.Statements, env)
+		if err != nil {
+			return err
 		}
 
 		poppedObject, err := env.Stack.Pop()
@@ -118,10 +193,54 @@ func evalPushArray(node *ast.ArrayStatement, env *object.Env) error {
 
 		// Pop any excess objects
 		removeCount := env.Stack.Len() - initialLength
-		_, _ = env.Stack.PopMany(removeCount)
+		_ = env.Stack.Drop(removeCount)
 	}
 
 	obj.Value = elements
+	if err := CheckSize(obj, env); err != nil {
+		return err
+	}
+	env.Stack.Push(obj)
+
+	return nil
+}
+
+// evalPushTable pushes an object into the stack.
+func evalPushTable(node *ast.TableStatement, env *object.Env) (err error) {
+	obj := object.NewObj()
+
+	initialLength := env.Stack.Len()
+	defer func() {
+		// Pop any excess objects, including the partly built literal
+		// if an error or a <br>, <rb> or <a> left it
+		keep := initialLength + 1
+		if err != nil {
+			keep = initialLength
+		}
+		_ = env.Stack.Drop(env.Stack.Len() - keep)
+	}()
+
+	for _, row := range node.Rows {
+		err := evalBlock(row.Statements, env)
+		if err != nil {
+			return err
+		}
+
+		poppedObject, err := env.Stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		obj.Set(row.Key, poppedObject)
+
+		// Pop any excess objects
+		removeCount := env.Stack.Len() - initialLength
+		_ = env.Stack.Drop(removeCount)
+	}
+
+	if err := CheckSize(obj, env); err != nil {
+		return err
+	}
 	env.Stack.Push(obj)
 
 	return nil
@@ -138,16 +257,41 @@ func evalBinOp(node *ast.BinaryOpStatement, env *object.Env) error {
 	right := objects[0]
 	left := objects[1]
 
+	var result object.Object
+	switch node.Op {
+	case ast.BinGreaterThan, ast.BinLessThan:
+		result, err = evalComparison(node.Op, left, right)
+	case ast.BinEqual:
+		result, err = evalEquality(node.Op, left, right, env)
+	case ast.BinAnd, ast.BinOr:
+		result, err = evalLogical(node.Op, left, right, env)
+	default:
+		result, err = evalArithmetic(node.Op, left, right)
+	}
+	if err != nil {
+		return err
+	}
+	if err := CheckSize(result, env); err != nil {
+		return err
+	}
+
+	_ = env.Stack.Drop(2)
+	env.Stack.Push(result)
+	return nil
+}
+
+// evalArithmetic performs a math operation on two values.
+func evalArithmetic(op ast.BinaryOp, left, right object.Object) (object.Object, error) {
 	switch {
 	case left.Type() != right.Type():
-		return errs.NewTypeError("cannot perform %v on types '%v' and '%v'",
-			node.Op, left.Type(), right.Type())
+		return nil, errs.NewTypeError("cannot perform %v on types '%v' and '%v'",
+			op, left.Type(), right.Type())
 	case left.Type() == object.NumberType && right.Type() == object.NumberType:
 		leftNumber := left.(*object.Number).Value
 		rightNumber := right.(*object.Number).Value
 
 		number := 0.0
-		switch node.Op {
+		switch op {
 		case ast.BinAdd:
 			number = leftNumber + rightNumber
 		case ast.BinSubtract:
@@ -158,26 +302,242 @@ func evalBinOp(node *ast.BinaryOpStatement, env *object.Env) error {
 			number = leftNumber / rightNumber
 		}
 
-		object := &object.Number{Value: number}
-
-		_, _ = env.Stack.PopMany(2)
-		env.Stack.Push(object)
-		return nil
-	case left.Type() == object.StringType && right.Type() == object.StringType && node.Op == ast.BinAdd:
+		return object.NewNumber(number), nil
+	case left.Type() == object.StringType && right.Type() == object.StringType && op == ast.BinAdd:
 		leftString := left.(*object.String).Value
 		rightString := right.(*object.String).Value
 
-		object := &object.String{Value: leftString + rightString}
+		return &object.String{Value: leftString + rightString}, nil
+	default:
+		return nil, errs.NewTypeError("cannot perform %v on type '%v'",
+			op, left.Type())
+	}
+}
+
+// evalComparison orders two values of type Number or String.
+//
+// Strings are compared lexicographically.
+func evalComparison(op ast.BinaryOp, left, right object.Object) (object.Object, error) {
+	var ordering int
 
-		_, _ = env.Stack.PopMany(2)
-		env.Stack.Push(object)
-		return nil
+	switch {
+	case left.Type() != right.Type():
+		return nil, errs.NewTypeError("cannot perform %v on types '%v' and '%v'",
+			op, left.Type(), right.Type())
+	case left.Type() == object.NumberType:
+		ordering = cmp.Compare(left.(*object.Number).Value, right.(*object.Number).Value)
+	case left.Type() == object.StringType:
+		ordering = cmp.Compare(left.(*object.String).Value, right.(*object.String).Value)
+	default:
+		return nil, errs.NewTypeError("cannot perform %v on type '%v'",
+			op, left.Type())
+	}
+
+	switch op {
+	case ast.BinGreaterThan:
+		return object.NewBool(ordering > 0), nil
+	default:
+		return object.NewBool(ordering < 0), nil
+	}
+}
+
+// evalEquality checks if two values are deeply equal.
+//
+// Null can be compared with any type, but other values must share the same type.
+func evalEquality(op ast.BinaryOp, left, right object.Object, env *object.Env) (object.Object, error) {
+	if left.Type() != right.Type() &&
+		left.Type() != object.NullType && right.Type() != object.NullType {
+		return nil, errs.NewTypeError("cannot perform %v on types '%v' and '%v'",
+			op, left.Type(), right.Type())
+	}
+
+	equal, err := object.EqualVisit(left, right, cancelVisitor(env))
+	if err != nil {
+		return nil, err
+	}
+	return object.NewBool(equal), nil
+}
+
+// evalLogical performs a logical operation on two Bool values.
+func evalLogical(op ast.BinaryOp, left, right object.Object, env *object.Env) (object.Object, error) {
+	leftBool, err := ToBool(op.String(), left, env)
+	if err != nil {
+		return nil, err
+	}
+	rightBool, err := ToBool(op.String(), right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case ast.BinAnd:
+		return object.NewBool(leftBool && rightBool), nil
 	default:
-		return errs.NewTypeError("cannot perform %v on type '%v'",
-			node.Op, left.Type())
+		return object.NewBool(leftBool || rightBool), nil
+	}
+}
+
+// evalNot negates the top value of the stack.
+func evalNot(_ *ast.NotStatement, env *object.Env) error {
+	obj, err := env.Stack.Peek()
+	if err != nil {
+		return err
+	}
+
+	value, err := ToBool("logical not", obj, env)
+	if err != nil {
+		return err
+	}
+
+	_, _ = env.Stack.Pop()
+	env.Stack.Push(object.NewBool(!value))
+	return nil
+}
+
+// ToBool converts an object into a boolean.
+//
+// Only Bool values are accepted unless truthiness is enabled in the environment.
+func ToBool(operation string, obj object.Object, env *object.Env) (bool, error) {
+	if b, ok := obj.(*object.Bool); ok {
+		return b.Value, nil
+	}
+	if env.Options.Truthiness {
+		return object.Truthy(obj), nil
+	}
+	return false, errs.NewTypeError("cannot perform %v on type '%v'", operation, obj.Type())
+}
+
+// evalIf pops a condition off the stack and evaluates
+// the matching branch of an if statement.
+func evalIf(node *ast.IfStatement, env *object.Env) error {
+	obj, err := env.Stack.Peek()
+	if err != nil {
+		return err
+	}
+
+	condition, err := ToBool("conditional", obj, env)
+	if err != nil {
+		return err
+	}
+	_, _ = env.Stack.Pop()
+
+	if condition {
+		return evalBlock(node.Consequence, env)
+	}
+	return evalBlock(node.Alternative, env)
+}
+
+// evalLoop repeatedly evaluates the loop body while
+// the condition popped off the stack is true.
+func evalLoop(node *ast.LoopStatement, env *object.Env) error {
+	for iterations := 0; ; iterations++ {
+		obj, err := env.Stack.Peek()
+		if err != nil {
+			return err
+		}
+
+		condition, err := ToBool("loop", obj, env)
+		if err != nil {
+			return err
+		}
+		_, _ = env.Stack.Pop()
+
+		if !condition {
+			return nil
+		}
+
+		if limit := env.Options.MaxIterations; limit > 0 && iterations >= limit {
+			return errs.NewLoopError("loop exceeded the maximum of %v iterations", limit)
+		}
+
+		err = evalBlock(node.Statements, env)
+		switch err {
+		case nil, errContinue:
+		case errBreak:
+			return nil
+		default:
+			return err
+		}
 	}
 }
 
+// evalBlock evaluates a list of statements in order.
+//
+// Jumps to a label inside the block move the instruction pointer to the
+// labelled statement, while other jumps are passed on to the enclosing block.
+func evalBlock(statements []ast.Node, env *object.Env) error {
+	for ip := 0; ip < len(statements); ip++ {
+		// Breakpoints hand control to the debugger by themselves,
+		// and are not counted as statements
+		if _, ok := statements[ip].(*ast.BreakpointStatement); !ok {
+			if err := beforeStatement(statements[ip], env); err != nil {
+				pos := statements[ip].Info().Pos
+				return errs.At(err, pos.Line, pos.Column)
+			}
+		}
+
+		err := Exec(statements[ip], env)
+		if err == nil {
+			if err := CheckStackDepth(env); err != nil {
+				pos := statements[ip].Info().Pos
+				return errs.At(err, pos.Line, pos.Column)
+			}
+			continue
+		}
+
+		j, ok := err.(jump)
+		if !ok {
+			return err
+		}
+
+		target := slices.IndexFunc(statements, func(stmt ast.Node) bool {
+			return stmt.Info().ID == j.label
+		})
+		if target == -1 {
+			return err
+		}
+
+		// Offset the increment at the end of the loop
+		ip = target - 1
+	}
+	return nil
+}
+
+// beforeStatement counts a statement in a block that is about to be executed,
+// and breaks in the debugger first while stepping.
+func beforeStatement(node ast.Node, env *object.Env) error {
+	if err := CountStatement(env); err != nil {
+		return err
+	}
+	if env.Stepping {
+		return DebugBreak(node, env)
+	}
+	return nil
+}
+
+// evalJump jumps to the statement with the given label, popping
+// a condition off the stack first if the jump is conditional.
+func evalJump(node *ast.JumpStatement, env *object.Env) error {
+	if node.Conditional {
+		obj, err := env.Stack.Peek()
+		if err != nil {
+			return err
+		}
+
+		condition, err := ToBool("conditional jump", obj, env)
+		if err != nil {
+			return err
+		}
+		_, _ = env.Stack.Pop()
+
+		if !condition {
+			return nil
+		}
+	}
+
+	return jump{label: node.Label}
+}
+
 // evalDuplicate duplicates the top value on the stack.
 func evalDuplicate(_ *ast.DuplicateStatement, env *object.Env) error {
 	object, err := env.Stack.Peek()
@@ -202,7 +562,7 @@ func evalSetVariable(node *ast.SetVariableStatement, env *object.Env) error {
 		return err
 	}
 
-	err = env.Vars.Set(node.Identifier, object)
+	err = SetVariable(node.Identifier, object, env)
 	if err != nil {
 		return err
 	}
@@ -220,12 +580,404 @@ func evalGetVariable(node *ast.GetVariableStatement, env *object.Env) error {
 	return nil
 }
 
+// evalGetProperty pops a value off the stack and pushes one of its properties.
+func evalGetProperty(node *ast.GetPropertyStatement, env *object.Env) error {
+	container, err := env.Stack.Peek()
+	if err != nil {
+		return err
+	}
+
+	value, err := getProperty(container, &object.String{Value: node.Property})
+	if err != nil {
+		return err
+	}
+
+	_, _ = env.Stack.Pop()
+	env.Stack.Push(value)
+	return nil
+}
+
+// evalSetProperty pops a value off the stack and stores it
+// as a property of the value below it.
+func evalSetProperty(node *ast.SetPropertyStatement, env *object.Env) error {
+	objects, err := env.Stack.PeekMany(2)
+	if err != nil {
+		return err
+	}
+
+	value := objects[0]
+	container := objects[1]
+
+	err = setProperty(container, &object.String{Value: node.Property}, value)
+	if err != nil {
+		return err
+	}
+	if err := CheckSize(container, env); err != nil {
+		return err
+	}
+
+	_, _ = env.Stack.Pop()
+	return nil
+}
+
+// evalGetDynamicProperty pops a key and a value off the stack
+// and pushes the property of the value with that key.
+func evalGetDynamicProperty(_ *ast.GetDynamicPropertyStatement, env *object.Env) error {
+	objects, err := env.Stack.PeekMany(2)
+	if err != nil {
+		return err
+	}
+
+	key := objects[0]
+	container := objects[1]
+
+	value, err := getProperty(container, key)
+	if err != nil {
+		return err
+	}
+
+	_ = env.Stack.Drop(2)
+	env.Stack.Push(value)
+	return nil
+}
+
+// evalSetDynamicProperty pops a value and a key off the stack and stores
+// the value as the property with that key of the value below them.
+//
+// Setting the index right after the last element of an array appends to it.
+func evalSetDynamicProperty(_ *ast.SetDynamicPropertyStatement, env *object.Env) error {
+	objects, err := env.Stack.PeekMany(3)
+	if err != nil {
+		return err
+	}
+
+	value := objects[0]
+	key := objects[1]
+	container := objects[2]
+
+	err = setProperty(container, key, value)
+	if err != nil {
+		return err
+	}
+	if err := CheckSize(container, env); err != nil {
+		return err
+	}
+
+	_ = env.Stack.Drop(2)
+	return nil
+}
+
+// getProperty returns the property of a container value with the given key.
+//
+// Arrays and strings are indexed by numbers and have a 'length' property,
+// while objects are indexed by strings.
+func getProperty(container object.Object, key object.Object) (object.Object, error) {
+	if isLengthKey(key) {
+		switch container := container.(type) {
+		case *object.Array:
+			return object.NewNumber(float64(len(container.Value))), nil
+		case *object.String:
+			return object.NewNumber(float64(utf8.RuneCountInString(container.Value))), nil
+		}
+	}
+
+	switch container := container.(type) {
+	case *object.Array:
+		index, err := toIndex(key, len(container.Value))
+		if err != nil {
+			return nil, err
+		}
+		return container.Value[index], nil
+	case *object.String:
+		runes := []rune(container.Value)
+		index, err := toIndex(key, len(runes))
+		if err != nil {
+			return nil, err
+		}
+		return &object.String{Value: string(runes[index])}, nil
+	case *object.Obj:
+		keyString, ok := key.(*object.String)
+		if !ok {
+			return nil, errs.NewTypeError("cannot use type '%v' as an object key", key.Type())
+		}
+		value, ok := container.Get(keyString.Value)
+		if !ok {
+			return nil, errs.NewAttributeError("object has no property '%v'", keyString.Value)
+		}
+		return value, nil
+	default:
+		return nil, errs.NewTypeError("cannot get property of type '%v'", container.Type())
+	}
+}
+
+// setProperty stores a value as the property of a container value with the given key.
+func setProperty(container object.Object, key object.Object, value object.Object) error {
+	switch container := container.(type) {
+	case *object.Array:
+		if isLengthKey(key) {
+			return errs.NewAttributeError("cannot set property 'length'")
+		}
+
+		// Allow appending with the index right after the last element
+		index, err := toIndex(key, len(container.Value)+1)
+		if err != nil {
+			return err
+		}
+		if err := checkNesting(container, value); err != nil {
+			return err
+		}
+		if index == len(container.Value) {
+			container.Value = append(container.Value, value)
+		} else {
+			container.Value[index] = value
+		}
+		return nil
+	case *object.Obj:
+		keyString, ok := key.(*object.String)
+		if !ok {
+			return errs.NewTypeError("cannot use type '%v' as an object key", key.Type())
+		}
+		if err := checkNesting(container, value); err != nil {
+			return err
+		}
+		container.Set(keyString.Value, value)
+		return nil
+	default:
+		return errs.NewTypeError("cannot set property of type '%v'", container.Type())
+	}
+}
+
+// checkNesting checks that storing a value in a container
+// does not make the container contain itself.
+func checkNesting(container object.Object, value object.Object) error {
+	if object.Contains(value, container) {
+		return errs.NewTypeError("cannot store a value of type '%v' inside itself", container.Type())
+	}
+	return nil
+}
+
+// isLengthKey checks if a key refers to the 'length' property.
+func isLengthKey(key object.Object) bool {
+	keyString, ok := key.(*object.String)
+	return ok && keyString.Value == "length"
+}
+
+// toIndex converts a key into an index in the range [0, length).
+//
+// Keys can be numbers, or strings from static properties like <rp title="0">.
+func toIndex(key object.Object, length int) (int, error) {
+	var number float64
+	switch key := key.(type) {
+	case *object.Number:
+		number = key.Value
+	case *object.String:
+		parsed, err := strconv.Atoi(key.Value)
+		if err != nil {
+			return 0, errs.NewIndexError("'%v' is not a valid index", key.Value)
+		}
+		number = float64(parsed)
+	default:
+		return 0, errs.NewTypeError("cannot use type '%v' as an index", key.Type())
+	}
+
+	if number != math.Trunc(number) {
+		return 0, errs.NewIndexError("index %v is not an integer", number)
+	}
+	if number < 0 || number >= float64(length) {
+		return 0, errs.NewIndexError("index %v is out of bounds for length %v", number, length)
+	}
+
+	return int(number), nil
+}
+
+// evalPushFunction pushes a function that closes over the current scope into the stack.
+func evalPushFunction(node *ast.FunctionStatement, env *object.Env) error {
+	env.Stack.Push(&object.Function{
+		Body:  node.Statements,
+		Scope: env.Vars,
+	})
+	return nil
+}
+
+// evalCall pops a function off the stack and calls it.
+//
+// The function shares the stack with its caller, but gets its own
+// variable scope layered over the scope it was defined in.
+func evalCall(_ *ast.CallStatement, env *object.Env) error {
+	obj, err := env.Stack.Peek()
+	if err != nil {
+		return err
+	}
+
+	var function *object.Function
+	switch obj := obj.(type) {
+	case *object.Function:
+		function = obj
+	case *object.HostFunction:
+		return CallHost(obj, env)
+	default:
+		return errs.NewTypeError("cannot call value of type '%v'", obj.Type())
+	}
+
+	if limit := env.Options.MaxCallDepth; limit > 0 && env.CallDepth >= limit {
+		return errs.NewRecursionError("exceeded the maximum call depth of %v", limit)
+	}
+	_, _ = env.Stack.Pop()
+
+	callerVars := env.Vars
+	env.Vars = function.Scope.NewScope()
+	env.CallDepth++
+	defer func() {
+		env.Vars = callerVars
+		env.CallDepth--
+	}()
+
+	return evalBlock(function.Body, env)
+}
+
+// CallHost pops a host function and its arguments off the stack,
+// calls it and pushes the values it returns.
+func CallHost(function *object.HostFunction, env *object.Env) error {
+	capabilities := env.Options.Capabilities
+	if !capabilities.HostFunctions {
+		return errs.NewPermissionError("calling host function '%v' is not allowed", function.Name)
+	}
+	if function.Files && !capabilities.Files {
+		return errs.NewPermissionError("function '%v' accesses files, which is not allowed", function.Name)
+	}
+
+	arity := len(function.Params)
+
+	objects, err := env.Stack.PeekMany(arity + 1)
+	if err != nil {
+		return errs.NewStackError("function '%v' takes %v arguments, but the stack only has %v values",
+			function.Name, arity, env.Stack.Len()-1)
+	}
+
+	// Arguments are passed from the bottom of the stack to the top,
+	// in a copy as the host function may keep them
+	args := slices.Clone(objects[1:])
+	slices.Reverse(args)
+
+	for i, param := range function.Params {
+		if param != object.AnyType && param != args[i].Type() {
+			return errs.NewTypeError("argument %v of function '%v' must be of type '%v', found '%v'",
+				i+1, function.Name, param, args[i].Type())
+		}
+	}
+
+	results, err := function.Fn(args)
+	if err != nil {
+		var hypoErr errs.Error
+		if errors.As(err, &hypoErr) {
+			return err
+		}
+		return errs.NewHostError("function '%v' failed: %v", function.Name, err).Wrap(err)
+	}
+	for _, result := range results {
+		if err := CheckSize(result, env); err != nil {
+			return err
+		}
+	}
+
+	_ = env.Stack.Drop(arity + 1)
+	for _, result := range results {
+		env.Stack.Push(result)
+	}
+	return nil
+}
+
 // evalPrint prints the top value without consuming it to stdout.
-func evalPrint(_ *ast.PrintStatement, env *object.Env) error {
-	object, err := env.Stack.Peek()
+//
+// Strings are printed without quotes in raw mode.
+func evalPrint(node *ast.PrintStatement, env *object.Env) error {
+	if !env.Options.Capabilities.Stdout {
+		return errs.NewPermissionError("writing output is not allowed")
+	}
+
+	obj, err := env.Stack.Peek()
+	if err != nil {
+		return err
+	}
+
+	out := env.Stdout
+	if node.Stderr {
+		out = env.Stderr
+	}
+
+	text, err := object.StringVisit(obj, cancelVisitor(env))
 	if err != nil {
 		return err
 	}
-	fmt.Println(object.String())
+	if str, ok := obj.(*object.String); ok && (node.Raw || env.Options.RawOutput) {
+		text = str.Value
+	}
+	if !node.NoNewline {
+		text += "\n"
+	}
+
+	_, err = io.WriteString(out, text)
+	if err != nil {
+		return errs.NewIOError("cannot write output: %v", err).Wrap(err)
+	}
+	return nil
+}
+
+// evalInput reads a line from stdin and pushes it into the stack.
+//
+// Null is pushed if there is no more input.
+func evalInput(node *ast.InputStatement, env *object.Env) error {
+	if !env.Options.Capabilities.Stdin {
+		return errs.NewPermissionError("reading input is not allowed")
+	}
+
+	line, err := env.Stdin.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return errs.NewIOError("cannot read input: %v", err).Wrap(err)
+	}
+	if errors.Is(err, io.EOF) && line == "" {
+		env.Stack.Push(&object.Null{})
+		return nil
+	}
+
+	line = strings.TrimRight(line, "\r\n")
+
+	if !node.Number {
+		str := &object.String{Value: line}
+		if err := CheckSize(str, env); err != nil {
+			return err
+		}
+		env.Stack.Push(str)
+		return nil
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(line), 64)
+	if err != nil {
+		return errs.NewTypeError("input '%v' is not a valid number", line).Wrap(err)
+	}
+	env.Stack.Push(object.NewNumber(number))
+	return nil
+}
+
+// evalBreakpoint pauses execution and hands control to the debugger.
+func evalBreakpoint(node *ast.BreakpointStatement, env *object.Env) error {
+	return DebugBreak(node, env)
+}
+
+// DebugBreak hands control to the debugger before a statement is evaluated.
+func DebugBreak(node ast.Node, env *object.Env) error {
+	if env.Debugger == nil {
+		return nil
+	}
+
+	switch env.Debugger.Break(node, env) {
+	case object.DebugStep:
+		env.Stepping = true
+	case object.DebugAbort:
+		env.Stepping = false
+		return errs.NewAbortError("execution aborted by debugger")
+	default:
+		env.Stepping = false
+	}
 	return nil
 }
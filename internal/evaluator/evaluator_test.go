@@ -0,0 +1,86 @@
+package evaluator_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	errs "github.com/angelofallars/hypo/internal/errors"
+	"github.com/angelofallars/hypo/internal/evaluator"
+	"github.com/angelofallars/hypo/internal/object"
+	"github.com/angelofallars/hypo/internal/parser"
+)
+
+// countingLoop counts from 0 to 1000.
+const countingLoop = `
+<data value="0"></data><var title="i"></var>
+<cite>true</cite>
+<rt>
+  <cite>i</cite><data value="1"></data><dd></dd><var title="i"></var>
+  <cite>i</cite><data value="1000"></data><small></small>
+</rt>
+`
+
+func BenchmarkCountingLoop(b *testing.B) {
+	program, err := parser.Parse(countingLoop)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		env := object.NewEnv()
+		if err := evaluator.Exec(program, env); err != nil {
+			b.Fatal(err)
+		}
+		if i == 0 {
+			count, err := env.Vars.Get("i")
+			if err != nil {
+				b.Fatal(err)
+			}
+			if count.String() != "1000" {
+				b.Fatalf("i = %v, want 1000", count)
+			}
+		}
+	}
+}
+
+// TestCancelNestedValues checks that printing and comparing a value that
+// holds the same array many times stop once the context is canceled.
+func TestCancelNestedValues(t *testing.T) {
+	// The array holds 10^8 numbers
+	var nested object.Object = object.NewNumber(1)
+	for i := 0; i < 8; i++ {
+		elements := make([]object.Object, 10)
+		for j := range elements {
+			elements[j] = nested
+		}
+		nested = &object.Array{Value: elements}
+	}
+
+	for name, source := range map[string]string{
+		"print":    `<output></output>`,
+		"equality": `<dt></dt><em></em>`,
+	} {
+		source := source
+		t.Run(name, func(t *testing.T) {
+			program, err := parser.Parse(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			env := object.NewEnv()
+			env.Context = ctx
+			env.Stdout = io.Discard
+			env.Stack.Push(nested)
+
+			err = evaluator.Exec(program, env)
+			if !errors.Is(err, errs.ErrCanceled) {
+				t.Errorf("error = %v, want a CancelError", err)
+			}
+		})
+	}
+}
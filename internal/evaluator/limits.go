@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	errs "github.com/angelofallars/hypo/internal/errors"
+	"github.com/angelofallars/hypo/internal/object"
+)
+
+// cancelCheckInterval is the number of statements executed between checks
+// of whether the context was canceled, as checking takes a lock.
+const cancelCheckInterval = 256
+
+// CountStatement is called before each statement in a block is executed.
+//
+// It enforces the statement limit, and stops execution
+// if the environment's context was canceled.
+func CountStatement(env *object.Env) error {
+	env.Statements++
+
+	if limit := env.Options.MaxStatements; limit > 0 && env.Statements > limit {
+		return errs.NewStatementLimitError("program exceeded the maximum of %v statements", limit)
+	}
+	if env.Statements%cancelCheckInterval == 0 {
+		return CheckCanceled(env)
+	}
+	return nil
+}
+
+// CheckStackDepth enforces the stack depth limit. It is called after each
+// statement or instruction that can push values, so the error points at the
+// statement that pushed past the limit.
+func CheckStackDepth(env *object.Env) error {
+	if limit := env.Options.MaxStackDepth; limit > 0 && env.Stack.Len() > limit {
+		return errs.NewStackOverflowError("stack exceeded the maximum depth of %v values", limit)
+	}
+	return nil
+}
+
+// CheckCanceled returns a CancelError if the environment's context was canceled.
+func CheckCanceled(env *object.Env) error {
+	if err := env.Context.Err(); err != nil {
+		return errs.NewCancelError("execution was canceled: %v", err).Wrap(err)
+	}
+	return nil
+}
+
+// SetVariable stores a value in a variable of the current scope,
+// enforcing the limit on the number of variables in a scope.
+func SetVariable(identifier string, obj object.Object, env *object.Env) error {
+	limit := env.Options.MaxVariables
+	if limit > 0 && !env.Vars.Defines(identifier) && env.Vars.Len() >= limit {
+		return errs.NewVariableLimitError("cannot define variable '%v', the scope already has the maximum of %v variables",
+			identifier, limit)
+	}
+	return env.Vars.Set(identifier, obj)
+}
+
+// CheckSize enforces the size limit on strings, arrays and objects.
+//
+// The size of a string is its length in bytes, and the size of an array or
+// object is its number of elements plus the sizes of the values nested in it,
+// so values cannot grow past the limit by nesting containers.
+func CheckSize(obj object.Object, env *object.Env) error {
+	limit := env.Options.MaxValueSize
+	if limit <= 0 {
+		return nil
+	}
+
+	if size(obj, limit) > limit {
+		return errs.NewSizeLimitError("%v exceeds the maximum size of %v", obj.Type(), limit)
+	}
+	return nil
+}
+
+// size returns the size of a value, counting nested values.
+//
+// Counting stops once the size is over the limit, since containers
+// can hold the same value many times.
+func size(obj object.Object, limit int) int {
+	switch obj := obj.(type) {
+	case *object.String:
+		return len(obj.Value)
+	case *object.Array:
+		total := len(obj.Value)
+		for _, element := range obj.Value {
+			if total > limit {
+				break
+			}
+			total += size(element, limit-total)
+		}
+		return total
+	case *object.Obj:
+		total := len(obj.Keys)
+		for _, key := range obj.Keys {
+			if total > limit {
+				break
+			}
+			total += size(obj.Value[key], limit-total)
+		}
+		return total
+	}
+	return 0
+}
+
+// cancelVisitor returns a visit function for [object.StringVisit] and
+// [object.EqualVisit] that stops them once the environment's context is canceled.
+func cancelVisitor(env *object.Env) func() error {
+	visited := 0
+	return func() error {
+		visited++
+		if visited%cancelCheckInterval == 0 {
+			return CheckCanceled(env)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+// Package files finds the programs the commands work on.
+package files
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Find returns the .html files among the given paths,
+// searching directories recursively, in sorted order.
+func Find(paths ...string) ([]string, error) {
+	programs := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			programs = append(programs, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(path) == ".html" {
+				programs = append(programs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.Sort(programs)
+	return slices.Compact(programs), nil
+}
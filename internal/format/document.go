@@ -0,0 +1,119 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/angelofallars/hypo/internal/parser"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// document prints the children of a node of an HTML document.
+//
+// The elements around the program, like <html> and <head>, are only printed
+// if they have tags in the source code, and the entry point is replaced
+// with the formatted program.
+func (p *printer) document(node *html.Node, depth int) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.DoctypeNode, html.CommentNode:
+			p.newline(1, depth)
+			p.write(render(child))
+		case html.ElementNode:
+			p.documentElement(child, depth)
+		}
+	}
+}
+
+func (p *printer) documentElement(node *html.Node, depth int) {
+	entry := node == p.entry
+	if !entry && !isFrame(node) {
+		// Elements outside of the program, such as a <title>, are kept as they are
+		p.newline(1, depth)
+		p.write(render(node))
+		return
+	}
+
+	explicit := node.DataAtom == atom.Main || p.explicit[node.Data]
+	if !explicit {
+		if entry {
+			p.statements(depth)
+		} else {
+			p.document(node, depth)
+		}
+		return
+	}
+
+	p.newline(1, depth)
+	p.write(startTag(node))
+	p.opened = true
+	if entry {
+		p.statements(depth + 1)
+	} else {
+		p.document(node, depth+1)
+	}
+	p.newline(1, depth)
+	p.write("</" + node.Data + ">")
+}
+
+// statements prints the statements of the program.
+func (p *printer) statements(depth int) {
+	for i, statement := range p.program.Statements {
+		p.layout(statement.Info().Layout, depth, i == 0)
+		p.statement(statement, depth)
+	}
+	for _, comment := range p.program.EndLayout.Comments {
+		p.comment(comment, depth)
+	}
+}
+
+// isFrame checks if an element can hold the entry point of a program.
+func isFrame(node *html.Node) bool {
+	switch node.DataAtom {
+	case atom.Html, atom.Head, atom.Body:
+		return true
+	}
+	return false
+}
+
+// entryPoint returns the entry point of a document, which is
+// the <main> element if the <body> has one, or the <body> otherwise.
+func entryPoint(document *html.Node) *html.Node {
+	body := parser.FindElement(document, atom.Body)
+	if body == nil {
+		return nil
+	}
+	for child := body.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Main {
+			return child
+		}
+	}
+	return body
+}
+
+// explicitTags returns the names of the start tags in the source code.
+func explicitTags(source string) map[string]bool {
+	names := map[string]bool{}
+	for _, name := range parser.TagNames(source) {
+		names[name] = true
+	}
+	return names
+}
+
+// startTag returns the start tag of an element with its attributes.
+func startTag(node *html.Node) string {
+	var b strings.Builder
+	b.WriteString("<" + node.Data)
+	for _, attr := range node.Attr {
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// render returns the source code of a node.
+func render(node *html.Node) string {
+	var b strings.Builder
+	_ = html.Render(&b, node)
+	return b.String()
+}
@@ -0,0 +1,303 @@
+// Package format formats programs in a canonical style.
+//
+// Statements are printed from the AST, so numbers and attributes are
+// normalized, and elements that hold statements are indented by their depth
+// when they span several lines. Comments and the line breaks between
+// statements are kept, with at most one blank line in a row. Comments at
+// the end of consecutive lines are aligned.
+package format
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/angelofallars/hypo/internal/ast"
+	errs "github.com/angelofallars/hypo/internal/errors"
+	"github.com/angelofallars/hypo/internal/parser"
+	"golang.org/x/net/html"
+)
+
+// indentation is the indentation of each level of nesting.
+const indentation = "  "
+
+// Source formats the source code of a program.
+//
+// Source code that does not parse is returned as an error.
+func Source(source string) (string, error) {
+	program, err := parser.Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	document, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return "", errs.NewParseError("%v", err).Wrap(err)
+	}
+
+	p := &printer{
+		program:  program,
+		entry:    entryPoint(document),
+		explicit: explicitTags(source),
+	}
+	p.document(document, 0)
+	formatted := p.String()
+
+	// Formatting must never change what a program does
+	reparsed, err := parser.Parse(formatted)
+	if err != nil || reparsed.String() != program.String() {
+		return "", errors.New("formatting changed the meaning of the program")
+	}
+
+	return formatted, nil
+}
+
+// line is a line of formatted source code.
+type line struct {
+	depth int
+	code  string
+	// comment is a comment at the end of the line, which is aligned with
+	// the comments at the end of the lines around it.
+	comment string
+}
+
+type printer struct {
+	program *ast.Program
+	// entry is the element of the document that holds the program.
+	entry *html.Node
+	// explicit holds the names of the tags in the source code, since the
+	// HTML parser creates some elements even if they have no tags.
+	explicit map[string]bool
+	lines    []line
+	// opened is true right after a start tag that begins a line,
+	// where blank lines are not kept.
+	opened bool
+}
+
+// String returns the formatted source code.
+func (p *printer) String() string {
+	p.alignComments()
+
+	var b strings.Builder
+	for _, l := range p.lines {
+		text := l.code
+		if l.comment != "" {
+			text += " " + l.comment
+		}
+		if text != "" {
+			b.WriteString(strings.Repeat(indentation, l.depth) + text)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// alignComments pads the code of consecutive lines that end with comments
+// so that the comments start in the same column.
+func (p *printer) alignComments() {
+	aligned := func(l line) bool {
+		return l.code != "" && l.comment != "" && !strings.Contains(l.comment, "\n")
+	}
+
+	for start := 0; start < len(p.lines); start++ {
+		if !aligned(p.lines[start]) {
+			continue
+		}
+
+		end := start
+		width := 0
+		for ; end < len(p.lines) && aligned(p.lines[end]); end++ {
+			width = max(width, p.lines[end].width())
+		}
+		for i := start; i < end; i++ {
+			l := &p.lines[i]
+			l.code += strings.Repeat(" ", width-l.width())
+		}
+		start = end
+	}
+}
+
+// width returns the number of characters before the comment of a line.
+func (l line) width() int {
+	return len(indentation)*l.depth + utf8.RuneCountInString(l.code)
+}
+
+// newline starts new lines at a depth, keeping at most one blank line.
+func (p *printer) newline(count int, depth int) {
+	if count >= 2 && len(p.lines) > 0 && !p.opened {
+		p.lines = append(p.lines, line{})
+	}
+	p.lines = append(p.lines, line{depth: depth})
+	p.opened = false
+}
+
+// write adds code to the current line.
+func (p *printer) write(code string) {
+	if len(p.lines) == 0 {
+		p.lines = append(p.lines, line{})
+	}
+
+	l := &p.lines[len(p.lines)-1]
+	if l.comment != "" {
+		// The comment is no longer at the end of the line
+		l.code += " " + l.comment
+		l.comment = ""
+	}
+	l.code += code
+	p.opened = false
+}
+
+// comment adds a comment, on the current line if it follows code on it.
+func (p *printer) comment(comment ast.Comment, depth int) {
+	if comment.Newlines > 0 || len(p.lines) == 0 {
+		p.newline(comment.Newlines, depth)
+	}
+
+	l := &p.lines[len(p.lines)-1]
+	if l.code == "" {
+		l.code = comment.String()
+		return
+	}
+	if l.comment != "" {
+		l.code += " " + l.comment
+	}
+	l.comment = comment.String()
+}
+
+// layout prints the comments of a layout, then moves to where the next tag goes.
+//
+// Tags that begin a line in the source code are printed on a new line,
+// as are the first and last tags of a multiline block.
+func (p *printer) layout(layout ast.Layout, depth int, forceNewline bool) {
+	for _, comment := range layout.Comments {
+		p.comment(comment, depth)
+	}
+
+	newlines := layout.Newlines
+	if forceNewline {
+		newlines = max(newlines, 1)
+	}
+	if newlines > 0 && len(p.lines) > 0 {
+		p.newline(newlines, depth)
+	}
+}
+
+// block prints a block of statements and the comments before the tag that ends it.
+func (p *printer) block(statements []ast.Node, end ast.Layout, depth int, multiline bool) {
+	for i, statement := range statements {
+		p.layout(statement.Info().Layout, depth, multiline && i == 0)
+		p.statement(statement, depth)
+	}
+
+	for _, comment := range end.Comments {
+		p.comment(comment, depth)
+	}
+	if multiline {
+		// The end tag is printed at the depth of the start tag,
+		// without a blank line before it
+		p.newline(1, depth-1)
+	}
+}
+
+func (p *printer) statement(node ast.Node, depth int) {
+	start, end, ok := tags(node)
+	if !ok {
+		p.write(node.String())
+		return
+	}
+
+	multiline := isMultiline(node)
+	nested := blocks(node)
+
+	p.write(start)
+	p.opened = multiline
+	if ifStatement, ok := node.(*ast.IfStatement); ok && ifStatement.Alternative != nil {
+		p.block(nested[0], ifStatement.SeparatorLayout, depth+1, multiline)
+		p.write("<hr>")
+		p.opened = multiline
+		p.block(nested[1], ifStatement.EndLayout, depth+1, multiline)
+	} else {
+		p.block(nested[0], node.Info().EndLayout, depth+1, multiline)
+	}
+	p.write(end)
+}
+
+// tags returns the tags around the blocks of a statement that holds statements.
+func tags(node ast.Node) (start, end string, ok bool) {
+	switch node := node.(type) {
+	case *ast.ArrayStatement:
+		return node.StartTag("ol"), "</ol>", true
+	case *ast.ArrayElementStatement:
+		return node.StartTag("li"), "</li>", true
+	case *ast.TableStatement:
+		return node.StartTag("table"), "</table>", true
+	case *ast.TableRowStatement:
+		return node.StartTag("tr") + "<th>" + ast.EscapeText(node.Key) + "</th><td>", "</td></tr>", true
+	case *ast.IfStatement:
+		return node.StartTag("i"), "</i>", true
+	case *ast.LoopStatement:
+		return node.StartTag("rt"), "</rt>", true
+	case *ast.FunctionStatement:
+		return node.StartTag("dfn"), "</dfn>", true
+	}
+	return "", "", false
+}
+
+// isMultiline checks if a statement spans several lines in the source code.
+func isMultiline(node ast.Node) bool {
+	layouts := []ast.Layout{node.Info().EndLayout}
+	if node, ok := node.(*ast.IfStatement); ok {
+		layouts = append(layouts, node.SeparatorLayout)
+	}
+
+	for _, block := range blocks(node) {
+		for _, statement := range block {
+			if isMultiline(statement) {
+				return true
+			}
+			layouts = append(layouts, statement.Info().Layout)
+		}
+	}
+
+	for _, layout := range layouts {
+		if layout.Newlines > 0 {
+			return true
+		}
+		for _, comment := range layout.Comments {
+			if comment.Newlines > 0 || strings.Contains(comment.Text, "\n") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// blocks returns the blocks of statements nested in a statement,
+// with the elements of arrays and the rows of tables as statements.
+func blocks(node ast.Node) [][]ast.Node {
+	switch node := node.(type) {
+	case *ast.ArrayStatement:
+		elements := []ast.Node{}
+		for _, element := range node.Elements {
+			elements = append(elements, element)
+		}
+		return [][]ast.Node{elements}
+	case *ast.ArrayElementStatement:
+		return [][]ast.Node{node.Statements}
+	case *ast.TableStatement:
+		rows := []ast.Node{}
+		for _, row := range node.Rows {
+			rows = append(rows, row)
+		}
+		return [][]ast.Node{rows}
+	case *ast.TableRowStatement:
+		return [][]ast.Node{node.Statements}
+	case *ast.IfStatement:
+		return [][]ast.Node{node.Consequence, node.Alternative}
+	case *ast.LoopStatement:
+		return [][]ast.Node{node.Statements}
+	case *ast.FunctionStatement:
+		return [][]ast.Node{node.Statements}
+	}
+	return nil
+}
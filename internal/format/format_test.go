@@ -0,0 +1,127 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/angelofallars/hypo/internal/files"
+	"github.com/angelofallars/hypo/internal/parser"
+)
+
+// testdata is the directory of the test corpus, relative to this package.
+const testdata = "../../testdata"
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "numbers are normalized",
+			source: `<data value="1.50"></data><data value="02"></data><dd></dd><output></output>`,
+			want:   "<data value=\"1.5\"></data><data value=\"2\"></data><dd></dd><output></output>\n",
+		},
+		{
+			name:   "multiline elements are indented",
+			source: "<cite>true</cite><rt>\n<data value=\"1\"></data><del></del>\n<cite>false</cite></rt>",
+			want:   "<cite>true</cite><rt>\n  <data value=\"1\"></data><del></del>\n  <cite>false</cite>\n</rt>\n",
+		},
+		{
+			name:   "single line elements stay on one line",
+			source: `<cite>true</cite><rt><cite>false</cite></rt>`,
+			want:   "<cite>true</cite><rt><cite>false</cite></rt>\n",
+		},
+		{
+			name:   "blank lines are collapsed",
+			source: "<s>a</s>\n\n\n\n<output></output>",
+			want:   "<s>a</s>\n\n<output></output>\n",
+		},
+		{
+			name:   "comments are aligned",
+			source: "<s>a</s>   <!-- end -->\n<output></output> <!-- print -->\n<del></del>",
+			want:   "<s>a</s>          <!-- end -->\n<output></output> <!-- print -->\n<del></del>\n",
+		},
+		{
+			name:   "comments on their own lines",
+			source: "<!-- greet -->\n<s>hi</s><output></output>\n<!-- done -->",
+			want:   "<!-- greet -->\n<s>hi</s><output></output>\n<!-- done -->\n",
+		},
+		{
+			name: "doctype and document elements",
+			source: "<!DOCTYPE html>\n<html><head><title>t</title></head><body>\n" +
+				"<!-- greet --><s>hi</s><output></output><del></del>\n</body></html>",
+			want: "<!DOCTYPE html>\n<html>\n  <head>\n    <title>t</title>\n  </head>\n  <body>\n" +
+				"    <!-- greet -->\n    <s>hi</s><output></output><del></del>\n  </body>\n</html>\n",
+		},
+		{
+			name:   "entry point",
+			source: `<main><s>a</s><output></output></main>`,
+			want:   "<main>\n  <s>a</s><output></output>\n</main>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Source(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("formatting %q\ngot:\n%v\nwant:\n%v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	if _, err := Source(`<s>a</s><blink></blink>`); err == nil {
+		t.Error("formatting a program that does not parse succeeded")
+	}
+}
+
+// TestSourceCorpus checks that formatting every program in the corpus
+// keeps its meaning and is idempotent.
+func TestSourceCorpus(t *testing.T) {
+	programs, err := files.Find(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range programs {
+		path := path
+		name, _ := filepath.Rel(testdata, path)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			program, err := parser.Parse(string(source))
+			if err != nil {
+				t.Skip("the program does not parse")
+			}
+
+			formatted, err := Source(string(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			reparsed, err := parser.Parse(formatted)
+			if err != nil {
+				t.Fatalf("the formatted program does not parse: %v", err)
+			}
+			if reparsed.String() != program.String() {
+				t.Errorf("formatting changed the program:\n%v\nto:\n%v", program, reparsed)
+			}
+
+			again, err := Source(formatted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != formatted {
+				t.Errorf("formatting is not idempotent:\n%v\nwas formatted as:\n%v", formatted, again)
+			}
+		})
+	}
+}
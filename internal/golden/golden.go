@@ -0,0 +1,134 @@
+// Package golden compares the behavior of programs against golden files.
+//
+// The golden file of a program is stored next to it, with the .golden
+// extension instead of .html. It holds the expected standard output,
+// standard error and exit code in sections:
+//
+//	-- stdout --
+//	"Hello world!"
+//	-- stderr --
+//	-- exit code --
+//	0
+//
+// As in the txtar format, a section that does not end with a newline
+// is given one, so output without a final newline cannot be distinguished
+// from output with one.
+package golden
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/angelofallars/hypo/internal/diff"
+)
+
+const (
+	stdoutHeader   = "-- stdout --\n"
+	stderrHeader   = "-- stderr --\n"
+	exitCodeHeader = "-- exit code --\n"
+)
+
+// Result is the observable behavior of running a program.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Marshal encodes a result in the golden file format.
+func (r Result) Marshal() []byte {
+	var b strings.Builder
+	b.WriteString(stdoutHeader)
+	b.WriteString(withNewline(r.Stdout))
+	b.WriteString(stderrHeader)
+	b.WriteString(withNewline(r.Stderr))
+	b.WriteString(exitCodeHeader)
+	b.WriteString(strconv.Itoa(r.ExitCode) + "\n")
+	return []byte(b.String())
+}
+
+// Parse decodes a result from the golden file format.
+func Parse(data []byte) (Result, error) {
+	s := string(data)
+
+	stdout, ok := strings.CutPrefix(s, stdoutHeader)
+	if !ok {
+		return Result{}, fmt.Errorf("golden file must start with '%v'", strings.TrimSpace(stdoutHeader))
+	}
+	stdout, rest, ok := cutSection(stdout, stderrHeader)
+	if !ok {
+		return Result{}, fmt.Errorf("golden file has no '%v' section", strings.TrimSpace(stderrHeader))
+	}
+	stderr, exitCode, ok := cutSection(rest, exitCodeHeader)
+	if !ok {
+		return Result{}, fmt.Errorf("golden file has no '%v' section", strings.TrimSpace(exitCodeHeader))
+	}
+
+	code, err := strconv.Atoi(strings.TrimSpace(exitCode))
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid exit code '%v' in golden file", strings.TrimSpace(exitCode))
+	}
+
+	return Result{Stdout: stdout, Stderr: stderr, ExitCode: code}, nil
+}
+
+// cutSection splits s around the line holding a section header.
+func cutSection(s, header string) (before, after string, found bool) {
+	if rest, ok := strings.CutPrefix(s, header); ok {
+		return "", rest, true
+	}
+	before, after, found = strings.Cut(s, "\n"+header)
+	if found {
+		before += "\n"
+	}
+	return before, after, found
+}
+
+// Diff describes how a result differs from the expected one,
+// or returns an empty string if they are the same.
+func Diff(want, got Result) string {
+	diffs := []string{}
+	if d := diffText("stdout", want.Stdout, got.Stdout); d != "" {
+		diffs = append(diffs, d)
+	}
+	if d := diffText("stderr", want.Stderr, got.Stderr); d != "" {
+		diffs = append(diffs, d)
+	}
+	if want.ExitCode != got.ExitCode {
+		diffs = append(diffs, fmt.Sprintf("exit code: want %v, got %v", want.ExitCode, got.ExitCode))
+	}
+	return strings.Join(diffs, "\n")
+}
+
+// diffText shows every line that differs between two outputs as a unified diff.
+func diffText(name, want, got string) string {
+	want, got = withNewline(want), withNewline(got)
+	return strings.TrimSuffix(diff.Unified("want/"+name, "got/"+name, want, got), "\n")
+}
+
+// withNewline adds a newline to the end of non-empty text that lacks one.
+func withNewline(s string) string {
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		return s + "\n"
+	}
+	return s
+}
+
+// Path returns the path of the golden file of a program.
+func Path(program string) string {
+	return strings.TrimSuffix(program, filepath.Ext(program)) + ".golden"
+}
+
+// StdinPath returns the path of the file whose contents are given
+// to a program as standard input, if it exists.
+func StdinPath(program string) string {
+	return strings.TrimSuffix(program, filepath.Ext(program)) + ".stdin"
+}
+
+// FlagsPath returns the path of the file holding the command line flags
+// a program is run with, separated by whitespace, if it exists.
+func FlagsPath(program string) string {
+	return strings.TrimSuffix(program, filepath.Ext(program)) + ".flags"
+}
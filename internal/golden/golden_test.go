@@ -0,0 +1,32 @@
+package golden
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffShowsEveryChangedLine(t *testing.T) {
+	want := Result{Stdout: "1\n2\n3\n", ExitCode: 0}
+	got := Result{Stdout: "1\nx\n3\ny\n", ExitCode: 1}
+
+	d := Diff(want, got)
+	for _, line := range []string{"-2", "+x", "+y", "exit code: want 0, got 1"} {
+		if !strings.Contains(d, "\n"+line) {
+			t.Errorf("Diff() does not contain %q:\n%v", line, d)
+		}
+	}
+	if Diff(want, want) != "" {
+		t.Errorf("Diff() of equal results = %q, want an empty string", Diff(want, want))
+	}
+}
+
+func TestMarshalParse(t *testing.T) {
+	result := Result{Stdout: "\"a\"\n", Stderr: "error\n", ExitCode: 1}
+	parsed, err := Parse(result.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != result {
+		t.Errorf("Parse(Marshal()) = %+v, want %+v", parsed, result)
+	}
+}
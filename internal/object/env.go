@@ -1,8 +1,13 @@
 package object
 
 import (
+	"bufio"
+	"context"
+	"io"
+	"os"
 	"slices"
 
+	"github.com/angelofallars/hypo/internal/ast"
 	errs "github.com/angelofallars/hypo/internal/errors"
 )
 
@@ -10,26 +15,129 @@ import (
 type Env struct {
 	// Stack is the primary storage of values.
 	Stack stack
-	Vars  vars
+	// Vars is the innermost variable scope.
+	Vars *vars
+	// Options configure the behavior of the runtime.
+	Options Options
+	// CallDepth is the number of function calls currently being evaluated.
+	CallDepth int
+	// Statements is the number of statements executed by the current or last evaluation.
+	Statements int
+	// Context stops execution with a CancelError once it is canceled.
+	Context context.Context
+	// Stdin is where <input> reads lines from.
+	Stdin *bufio.Reader
+	// Stdout is where <output> writes values to.
+	Stdout io.Writer
+	// Stderr is where <output for="stderr"> writes values to.
+	Stderr io.Writer
+	// Debugger is called on <wbr> breakpoints. Breakpoints are ignored if it is nil.
+	Debugger Debugger
+	// Stepping makes the debugger break before every statement.
+	Stepping bool
 }
 
+// Debugger pauses execution to let the user inspect the environment.
+type Debugger interface {
+	// Break is called before the given statement is evaluated,
+	// and returns how execution should proceed.
+	Break(node ast.Node, env *Env) DebugAction
+}
+
+// DebugAction is how execution proceeds after a breakpoint.
+type DebugAction uint
+
+const (
+	// DebugContinue resumes execution until the next breakpoint.
+	DebugContinue DebugAction = iota
+	// DebugStep breaks again before the next statement.
+	DebugStep
+	// DebugAbort stops execution.
+	DebugAbort
+)
+
+// Options configure the behavior of the runtime.
+type Options struct {
+	// Truthiness allows values of any type to be used where a Bool is expected,
+	// using the rules of [object.Truthy].
+	Truthiness bool
+	// MaxIterations is the maximum number of iterations a single loop can run
+	// before raising a LoopError. Zero means there is no limit.
+	MaxIterations int
+	// MaxCallDepth is the maximum number of nested function calls
+	// before raising a RecursionError. Zero means there is no limit.
+	MaxCallDepth int
+	// RawOutput makes <output> print strings without quotes.
+	RawOutput bool
+	// MaxStatements is the maximum number of statements that can be executed
+	// before raising a StatementLimitError. Zero means there is no limit.
+	MaxStatements int
+	// MaxStackDepth is the maximum number of values in the stack
+	// before raising a StackOverflowError. Zero means there is no limit.
+	MaxStackDepth int
+	// MaxVariables is the maximum number of variables in a single scope,
+	// including the predefined true, false and null in the global scope,
+	// before raising a VariableLimitError. Zero means there is no limit.
+	MaxVariables int
+	// MaxValueSize is the maximum length of a string in bytes, or of an array
+	// or object in elements including the values nested in it, before raising
+	// a SizeLimitError. Zero means there is no limit.
+	MaxValueSize int
+	// Capabilities are the operations with side effects the program can perform.
+	Capabilities Capabilities
+}
+
+// Capabilities are the operations with side effects a program can perform.
+// Performing any other operation raises a PermissionError.
+type Capabilities struct {
+	// Stdin allows <input> to read from stdin.
+	Stdin bool
+	// Stdout allows <output> to write to stdout and stderr.
+	Stdout bool
+	// HostFunctions allows calling functions implemented in Go,
+	// whether they were registered on their own or in a module.
+	HostFunctions bool
+	// Files allows calling host functions that access the file system.
+	Files bool
+}
+
+var (
+	// AllCapabilities allows every operation. It is the default.
+	AllCapabilities = Capabilities{Stdin: true, Stdout: true, HostFunctions: true, Files: true}
+	// SandboxCapabilities only allows writing output.
+	SandboxCapabilities = Capabilities{Stdout: true}
+)
+
+// DefaultMaxCallDepth is the default maximum number of nested function calls.
+const DefaultMaxCallDepth = 1000
+
 // NewEnv returns a new [object.Env] instance.
 func NewEnv() *Env {
 	return &Env{
-		Stack: stack{make([]Object, 0, 256)},
-		Vars: vars{
+		Stack: stack{slice: make([]Object, 0, 256)},
+		Vars: &vars{
 			objects: map[string]Object{
 				// Start with standard variables for common values
-				"true":  &Bool{Value: true},
-				"false": &Bool{Value: false},
+				"true":  True,
+				"false": False,
 				"null":  &Null{},
 			},
 		},
+		Options: Options{
+			MaxCallDepth: DefaultMaxCallDepth,
+			Capabilities: AllCapabilities,
+		},
+		Context: context.Background(),
+		Stdin:   bufio.NewReader(os.Stdin),
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
 	}
 }
 
 type stack struct {
 	slice []Object
+	// peeked is reused by PeekMany to avoid allocating on every call.
+	peeked []Object
 }
 
 // Push inserts a value into the top of the stack.
@@ -39,37 +147,48 @@ func (s *stack) Push(v Object) {
 
 // Pop removes a value from the top of the stack and returns it.
 func (s *stack) Pop() (Object, error) {
-	v, err := s.Peek()
-	if err != nil {
+	if len(s.slice) == 0 {
 		return nil, errs.NewStackError("stack is empty")
 	}
 
 	topIndex := s.topIndex()
-	s.slice = slices.Delete(s.slice, topIndex, topIndex+1)
+	v := s.slice[topIndex]
+	// Clear the slot so the popped value can be garbage collected
+	s.slice[topIndex] = nil
+	s.slice = s.slice[:topIndex]
 	return v, nil
 }
 
-// PopMany removes several values from the top of the stack and returns them.
+// PopMany removes several values from the top of the stack and returns them,
+// from the top of the stack to the bottom.
+//
+// Use [stack.Drop] instead if the values are not needed.
 func (s *stack) PopMany(count int) ([]Object, error) {
 	if count > s.Len() {
 		return nil, errs.NewStackError("cannot pop more than the length of the entire stack")
 	}
-
-	objects := []Object{}
-	for count > 0 {
-		poppedObject, err := s.Pop()
-		if err != nil {
-			panic("PopMany: had pop error even after checking pop length")
-		}
-
-		objects = append(objects, poppedObject)
-
-		count -= 1
+	if count <= 0 {
+		return []Object{}, nil
 	}
 
+	objects := slices.Clone(s.slice[s.Len()-count:])
+	slices.Reverse(objects)
+	s.truncate(s.Len() - count)
 	return objects, nil
 }
 
+// Drop removes several values from the top of the stack.
+// Counts of zero or less do nothing.
+func (s *stack) Drop(count int) error {
+	if count > s.Len() {
+		return errs.NewStackError("cannot pop more than the length of the entire stack")
+	}
+	if count > 0 {
+		s.truncate(s.Len() - count)
+	}
+	return nil
+}
+
 // Peek returns a value from the top of the stack without consuming it.
 func (s *stack) Peek() (Object, error) {
 	if len(s.slice) == 0 {
@@ -79,24 +198,27 @@ func (s *stack) Peek() (Object, error) {
 	return s.slice[s.topIndex()], nil
 }
 
-// PeekMany returns values from the top of the stack without consuming them.
+// PeekMany returns values from the top of the stack without consuming them,
+// from the top of the stack to the bottom.
+//
+// The returned slice is reused by the next call to PeekMany,
+// so it must be copied to be kept.
 func (s *stack) PeekMany(count int) ([]Object, error) {
 	if count > s.Len() {
 		return nil, errs.NewStackError("cannot peek more than the length of the entire stack")
 	}
 
-	objects := []Object{}
-	idx := s.Len() - 1
-	for count > 0 {
-		peekedObject := s.slice[idx]
-
-		objects = append(objects, peekedObject)
-
-		count -= 1
-		idx -= 1
+	s.peeked = s.peeked[:0]
+	for i := s.topIndex(); i >= s.Len()-count; i-- {
+		s.peeked = append(s.peeked, s.slice[i])
 	}
 
-	return objects, nil
+	return s.peeked, nil
+}
+
+// Values returns a copy of the values in the stack, from bottom to top.
+func (s *stack) Values() []Object {
+	return slices.Clone(s.slice)
 }
 
 // Len returns the length of the stack.
@@ -109,20 +231,69 @@ func (s *stack) topIndex() int {
 	return len(s.slice) - 1
 }
 
+// truncate shortens the stack to a length, clearing the removed slots
+// so their values can be garbage collected.
+func (s *stack) truncate(length int) {
+	clear(s.slice[length:])
+	s.slice = s.slice[:length]
+}
+
+// Scope is a variable scope, for packages that need to save and restore [Env.Vars].
+type Scope = vars
+
 type vars struct {
 	objects map[string]Object
+	// parent is the enclosing scope, or nil for the global scope.
+	parent *vars
+}
+
+// NewScope returns a new variable scope layered over the current one.
+func (v *vars) NewScope() *vars {
+	return &vars{
+		objects: map[string]Object{},
+		parent:  v,
+	}
 }
 
-// Get retrieves a variable with the given identifier.
+// Get retrieves a variable with the given identifier,
+// searching enclosing scopes if it is not defined in the current one.
 func (v *vars) Get(identifier string) (Object, error) {
-	object, ok := v.objects[identifier]
-	if !ok {
-		return nil, errs.NewVariableError("variable '%v' is not defined", identifier)
+	for scope := v; scope != nil; scope = scope.parent {
+		if object, ok := scope.objects[identifier]; ok {
+			return object, nil
+		}
 	}
-	return object, nil
+	return nil, errs.NewVariableError("variable '%v' is not defined", identifier)
+}
+
+// Identifiers returns the sorted identifiers of all variables visible from the current scope.
+func (v *vars) Identifiers() []string {
+	identifiers := []string{}
+	seen := map[string]bool{}
+	for scope := v; scope != nil; scope = scope.parent {
+		for identifier := range scope.objects {
+			if !seen[identifier] {
+				seen[identifier] = true
+				identifiers = append(identifiers, identifier)
+			}
+		}
+	}
+	slices.Sort(identifiers)
+	return identifiers
+}
+
+// Defines reports whether a variable with the given identifier is defined in the current scope.
+func (v *vars) Defines(identifier string) bool {
+	_, ok := v.objects[identifier]
+	return ok
+}
+
+// Len returns the number of variables defined in the current scope.
+func (v *vars) Len() int {
+	return len(v.objects)
 }
 
-// Set stores an object with the given identifier.
+// Set stores an object with the given identifier in the current scope.
 func (v *vars) Set(identifier string, object Object) error {
 	v.objects[identifier] = object
 	return nil
@@ -0,0 +1,34 @@
+package object
+
+import "testing"
+
+func BenchmarkStackPushPop(b *testing.B) {
+	env := NewEnv()
+	value := NewNumber(1)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			env.Stack.Push(value)
+		}
+		for j := 0; j < 100; j++ {
+			if _, err := env.Stack.Pop(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkPeekMany(b *testing.B) {
+	env := NewEnv()
+	for i := 0; i < 100; i++ {
+		env.Stack.Push(NewNumber(float64(i)))
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := env.Stack.PeekMany(2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,20 +2,25 @@ package object
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
-	"github.com/angelofallars/hypo/pkg/sliceutil"
+	"github.com/angelofallars/hypo/internal/ast"
 )
 
 type ObjectType string
 
 const (
-	NumberType ObjectType = "Number"
-	StringType ObjectType = "String"
-	BoolType   ObjectType = "Bool"
-	ObjType    ObjectType = "Obj"
-	NullType   ObjectType = "Null"
-	ArrayType  ObjectType = "Array"
+	NumberType   ObjectType = "Number"
+	StringType   ObjectType = "String"
+	BoolType     ObjectType = "Bool"
+	ObjType      ObjectType = "Obj"
+	NullType     ObjectType = "Null"
+	ArrayType    ObjectType = "Array"
+	FunctionType ObjectType = "Function"
+
+	// AnyType matches values of any type in a [HostFunction]'s parameters.
+	AnyType ObjectType = "Any"
 )
 
 // Dummy method to make the type enum-like.
@@ -33,6 +38,34 @@ type Number struct {
 	Value float64
 }
 
+// smallNumbers holds the shared values of the integers most commonly used
+// as counters and indices, so computing them does not allocate.
+var smallNumbers = func() []Number {
+	numbers := make([]Number, maxSmallNumber-minSmallNumber+1)
+	for i := range numbers {
+		numbers[i].Value = float64(i + minSmallNumber)
+	}
+	return numbers
+}()
+
+const (
+	minSmallNumber = -128
+	maxSmallNumber = 1023
+)
+
+// NewNumber returns a Number with the given value.
+//
+// Numbers are immutable, so small integers share a single preallocated value.
+func NewNumber(value float64) *Number {
+	// Negative zero is excluded as it is distinguishable from zero by division
+	isSmallInteger := value >= minSmallNumber && value <= maxSmallNumber &&
+		value == math.Trunc(value) && !(value == 0 && math.Signbit(value))
+	if isSmallInteger {
+		return &smallNumbers[int(value)-minSmallNumber]
+	}
+	return &Number{Value: value}
+}
+
 func (n *Number) Type() ObjectType { return NumberType }
 func (n *Number) String() string   { return fmt.Sprint(n.Value) }
 
@@ -47,6 +80,21 @@ type Bool struct {
 	Value bool
 }
 
+// True and False are the shared Bool values. Bools are immutable,
+// so there is no need to allocate more of them.
+var (
+	True  = &Bool{Value: true}
+	False = &Bool{Value: false}
+)
+
+// NewBool returns the shared Bool with the given value.
+func NewBool(value bool) *Bool {
+	if value {
+		return True
+	}
+	return False
+}
+
 func (b *Bool) Type() ObjectType { return BoolType }
 func (b *Bool) String() string   { return fmt.Sprint(b.Value) }
 
@@ -61,9 +109,238 @@ type Array struct {
 
 func (n *Array) Type() ObjectType { return ArrayType }
 func (n *Array) String() string {
-	displays := sliceutil.Map(n.Value,
-		func(obj Object) string { return obj.String() },
-	)
+	text, _ := StringVisit(n, nil)
+	return text
+}
+
+type Obj struct {
+	// Keys holds the keys of the object in insertion order.
+	Keys  []string
+	Value map[string]Object
+}
+
+// NewObj returns a new, empty [object.Obj].
+func NewObj() *Obj {
+	return &Obj{
+		Keys:  []string{},
+		Value: map[string]Object{},
+	}
+}
+
+func (o *Obj) Type() ObjectType { return ObjType }
+func (o *Obj) String() string {
+	text, _ := StringVisit(o, nil)
+	return text
+}
+
+// Get retrieves the value stored under a key.
+func (o *Obj) Get(key string) (Object, bool) {
+	value, ok := o.Value[key]
+	return value, ok
+}
+
+// Set stores a value under a key. New keys are appended to the key order,
+// while existing keys keep their original position.
+func (o *Obj) Set(key string, value Object) {
+	if _, ok := o.Value[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Value[key] = value
+}
+
+type Function struct {
+	Body []ast.Node
+	// Scope is the variable scope the function was defined in.
+	Scope *vars
+	// Code is the compiled form of Body used by the bytecode VM, if any.
+	Code any
+}
+
+func (f *Function) Type() ObjectType { return FunctionType }
+func (f *Function) String() string   { return "<function>" }
+
+// HostFunc is the implementation of a [HostFunction].
+//
+// It receives the arguments from the bottom of the stack to the top,
+// and returns values to push into the stack in order.
+type HostFunc func(args []Object) ([]Object, error)
+
+// HostFunction is a function implemented in Go.
+type HostFunction struct {
+	Name string
+	// Params are the types of the arguments, from the bottom of the stack to the top.
+	Params []ObjectType
+	Fn     HostFunc
+	// Files marks functions that access the file system,
+	// which can only be called if file access is allowed.
+	Files bool
+}
+
+func (hf *HostFunction) Type() ObjectType { return FunctionType }
+func (hf *HostFunction) String() string   { return "<function " + hf.Name + ">" }
+
+// StringVisit returns the string representation of an object, calling visit
+// before each value nested in it is represented. If visit returns an error,
+// StringVisit stops and returns it, so long representations can be interrupted.
+//
+// A nil visit function is never called.
+func StringVisit(obj Object, visit func() error) (string, error) {
+	var b strings.Builder
+	err := writeString(&b, obj, visit)
+	return b.String(), err
+}
+
+func writeString(b *strings.Builder, obj Object, visit func() error) error {
+	if visit != nil {
+		if err := visit(); err != nil {
+			return err
+		}
+	}
+
+	switch obj := obj.(type) {
+	case *Array:
+		b.WriteString("[")
+		for i, element := range obj.Value {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if err := writeString(b, element, visit); err != nil {
+				return err
+			}
+		}
+		b.WriteString("]")
+	case *Obj:
+		b.WriteString("{")
+		for i, key := range obj.Keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(key + ": ")
+			if err := writeString(b, obj.Value[key], visit); err != nil {
+				return err
+			}
+		}
+		b.WriteString("}")
+	default:
+		b.WriteString(obj.String())
+	}
+	return nil
+}
+
+// Equal reports whether two objects are deeply equal.
+//
+// Objects of different types are never equal. Arrays are equal if their
+// elements are equal in order, while objects are equal if they have the same
+// set of keys with equal values, regardless of key order.
+func Equal(a, b Object) bool {
+	equal, _ := EqualVisit(a, b, nil)
+	return equal
+}
+
+// EqualVisit reports whether two objects are deeply equal like [Equal], calling
+// visit before each pair of values nested in them is compared. If visit returns
+// an error, EqualVisit stops and returns it, so long comparisons can be interrupted.
+//
+// A nil visit function is never called.
+func EqualVisit(a, b Object, visit func() error) (bool, error) {
+	if visit != nil {
+		if err := visit(); err != nil {
+			return false, err
+		}
+	}
+
+	if a.Type() != b.Type() {
+		return false, nil
+	}
+
+	switch a := a.(type) {
+	case *Number:
+		return a.Value == b.(*Number).Value, nil
+	case *String:
+		return a.Value == b.(*String).Value, nil
+	case *Bool:
+		return a.Value == b.(*Bool).Value, nil
+	case *Null:
+		return true, nil
+	case *Array:
+		b := b.(*Array)
+		if len(a.Value) != len(b.Value) {
+			return false, nil
+		}
+		for i := range a.Value {
+			if equal, err := EqualVisit(a.Value[i], b.Value[i], visit); !equal || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	case *Obj:
+		b := b.(*Obj)
+		if len(a.Value) != len(b.Value) {
+			return false, nil
+		}
+		for key, value := range a.Value {
+			other, ok := b.Value[key]
+			if !ok {
+				return false, nil
+			}
+			if equal, err := EqualVisit(value, other, visit); !equal || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	return a == b, nil
+}
+
+// Contains reports whether a container is the value itself or is nested inside it.
+func Contains(value, container Object) bool {
+	visited := map[Object]bool{}
+
+	var walk func(obj Object) bool
+	walk = func(obj Object) bool {
+		if obj == container {
+			return true
+		}
+		if visited[obj] {
+			return false
+		}
+		visited[obj] = true
+
+		switch obj := obj.(type) {
+		case *Array:
+			for _, element := range obj.Value {
+				if walk(element) {
+					return true
+				}
+			}
+		case *Obj:
+			for _, element := range obj.Value {
+				if walk(element) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	return walk(value)
+}
 
-	return "[" + strings.Join(displays, ", ") + "]"
+// Truthy reports whether an object is considered true when used as a condition.
+//
+// This follows JavaScript semantics: false, null, 0, NaN and "" are falsy,
+// while every other value (including empty arrays and objects) is truthy.
+func Truthy(obj Object) bool {
+	switch obj := obj.(type) {
+	case *Bool:
+		return obj.Value
+	case *Null:
+		return false
+	case *Number:
+		return obj.Value != 0 && !math.IsNaN(obj.Value)
+	case *String:
+		return obj.Value != ""
+	}
+	return true
 }
@@ -7,6 +7,7 @@ package parser
 
 import (
 	"errors"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -22,12 +23,27 @@ import (
 type Parser struct {
 	curNode  *html.Node
 	peekNode *html.Node
+	// layout is the layout of the source code before curNode.
+	layout ast.Layout
+
+	// loopDepth is the number of loops enclosing the current node.
+	loopDepth int
+	// labels holds the element ids visible from the current block,
+	// with the innermost block last.
+	labels []map[string]bool
+	// ids holds every element id seen in the document.
+	ids map[string]bool
+	// positions holds the positions of elements in the source code.
+	positions map[*html.Node]ast.Position
 }
 
 func New() *Parser {
 	return &Parser{
-		curNode:  nil,
-		peekNode: nil,
+		curNode:   nil,
+		peekNode:  nil,
+		labels:    []map[string]bool{},
+		ids:       map[string]bool{},
+		positions: map[*html.Node]ast.Position{},
 	}
 }
 
@@ -38,60 +54,140 @@ func Parse(s string) (*ast.Program, error) {
 
 // Parse parses a string into Hypo-specific AST nodes.
 func (p *Parser) Parse(s string) (*ast.Program, error) {
-	if err := p.parseString(s); err != nil {
-		return nil, errs.NewParseError(err.Error())
+	root, err := p.parseString(s)
+	if err != nil {
+		return nil, err
 	}
 
 	program := &ast.Program{
 		Statements: []ast.Node{},
 	}
 
-	parseErrors := []error{}
-	for ; p.curNode != nil; p.nextNode() {
-		newNode, err := p.parseStatement()
-		if err != nil {
-			parseErrors = append(parseErrors, err)
-		}
-		program.Statements = append(program.Statements, newNode)
-	}
-
-	if len(parseErrors) != 0 {
-		return nil, errors.Join(parseErrors...)
+	statements, end, err := p.parseBlock(root.FirstChild, nil)
+	if err != nil {
+		return nil, err
 	}
+	program.Statements = statements
+	program.EndLayout = end
 
 	return program, nil
 }
 
-// parseString parses a string into an *[html.Node] tree.
-func (p *Parser) parseString(s string) error {
-	node, err := html.Parse(strings.NewReader(s))
+// parseString parses a string into an *[html.Node] tree,
+// and returns the element that is the program's entry point.
+//
+// The entry point is the <main> element if there is one, or the <body> otherwise.
+func (p *Parser) parseString(s string) (*html.Node, error) {
+	tags := scanTags(s)
+	if err := checkBodyCount(tags); err != nil {
+		return nil, err
+	}
+
+	node, err := html.Parse(strings.NewReader(markTags(s, tags)))
 	if err != nil {
-		return err
+		return nil, errs.NewParseError("%v", err).Wrap(err)
 	}
+	p.positions = matchPositions(node, tags)
 
-	//    <?> =><html>   =><head>   =><body>    =><[elem]>
-	node = node.FirstChild.FirstChild.NextSibling.FirstChild
-	p.peekNode = node
-	p.nextNode()
+	// The HTML parser always creates a <body>, even if the source has none
+	root := FindElement(node, atom.Body)
+	if root == nil {
+		return nil, errs.NewParseError("document has no <body> element")
+	}
+
+	mains := []*html.Node{}
+	for child := firstElementChild(root); child != nil; child = nextElementSibling(child) {
+		if child.DataAtom == atom.Main {
+			mains = append(mains, child)
+		}
+	}
+
+	switch len(mains) {
+	case 0:
+		// The <body> is the entry point
+	case 1:
+		for child := firstElementChild(root); child != nil; child = nextElementSibling(child) {
+			if child != mains[0] {
+				return nil, p.errorAt(child,
+					errs.NewParseError("<%v> element is outside of the <main> entry point", child.Data))
+			}
+		}
+		root = mains[0]
+	default:
+		return nil, p.errorAt(mains[1], errs.NewParseError("multiple <main> entry points declared"))
+	}
+
+	return root, nil
+}
+
+// checkBodyCount checks that the source declares at most one <body> element,
+// since the HTML parser silently merges multiple <body> elements into one.
+func checkBodyCount(tags []tagToken) error {
+	count := 0
+	for _, tag := range tags {
+		if atom.Lookup([]byte(tag.name)) != atom.Body {
+			continue
+		}
+
+		count++
+		if count > 1 {
+			return errs.At(errs.NewParseError("multiple <body> entry points declared"),
+				tag.pos.Line, tag.pos.Column)
+		}
+	}
 	return nil
 }
 
-// nextNode advances the parser's input nodes.
+// nextNode advances the parser's input nodes to the next element,
+// recording the comments and line breaks it skips in the parser's layout.
 func (p *Parser) nextNode() {
-	p.curNode = p.peekNode
-	if p.peekNode != nil {
-		p.peekNode = p.peekNode.NextSibling
+	p.layout = ast.Layout{}
+	for {
+		p.curNode = p.peekNode
+		if p.peekNode != nil {
+			p.peekNode = p.peekNode.NextSibling
+		}
+
+		if p.curNode == nil || p.curNode.Type == html.ElementNode {
+			return
+		}
+		addLayout(&p.layout, p.curNode)
 	}
+}
+
+// addLayout adds a node that is not an element to a layout.
+//
+// Text outside of elements is ignored, except for its line breaks.
+func addLayout(layout *ast.Layout, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		layout.Newlines += strings.Count(node.Data, "\n")
+	case html.CommentNode:
+		layout.Comments = append(layout.Comments, ast.Comment{Text: node.Data, Newlines: layout.Newlines})
+		layout.Newlines = 0
+	}
+}
 
-	if p.curNode != nil && p.curNode.Type != html.ElementNode {
-		p.nextNode()
+// joinLayouts returns the layout of the source code
+// where a layout is followed by another.
+func joinLayouts(first, second ast.Layout) ast.Layout {
+	if len(second.Comments) == 0 {
+		first.Newlines += second.Newlines
+		return first
 	}
+
+	comments := slices.Clone(second.Comments)
+	comments[0].Newlines += first.Newlines
+	first.Comments = append(slices.Clone(first.Comments), comments...)
+	first.Newlines = second.Newlines
+	return first
 }
 
 // parseStatement parses an AST statement from the current [html.Node].
 func (p *Parser) parseStatement() (ast.Node, error) {
 	var node ast.Node
 	var err error
+	layout := p.layout
 
 	switch p.curNode.DataAtom {
 	// ===============================
@@ -105,6 +201,10 @@ func (p *Parser) parseStatement() (ast.Node, error) {
 		node, err = p.parseArrayStatement()
 	case atom.Li:
 		node, err = p.parseArrayElementStatement()
+	case atom.Table:
+		node, err = p.parseTableStatement()
+	case atom.Tr:
+		node, err = p.parseTableRowStatement()
 
 	// ===============================
 	// Math commands
@@ -118,6 +218,26 @@ func (p *Parser) parseStatement() (ast.Node, error) {
 	case atom.Div:
 		node, err = p.parseBinaryOpStatement(ast.BinDivide)
 
+	// ===============================
+	// Comparison commands
+	// ===============================
+	case atom.Big:
+		node, err = p.parseBinaryOpStatement(ast.BinGreaterThan)
+	case atom.Small:
+		node, err = p.parseBinaryOpStatement(ast.BinLessThan)
+	case atom.Em:
+		node, err = p.parseBinaryOpStatement(ast.BinEqual)
+
+	// ===============================
+	// Logical operators
+	// ===============================
+	case atom.B:
+		node, err = p.parseBinaryOpStatement(ast.BinAnd)
+	case atom.Bdo:
+		node, err = p.parseBinaryOpStatement(ast.BinOr)
+	case atom.Bdi:
+		node, err = p.parseNotStatement()
+
 	// ===============================
 	// Stack manipulation commands
 	// ===============================
@@ -126,6 +246,20 @@ func (p *Parser) parseStatement() (ast.Node, error) {
 	case atom.Del:
 		node, err = p.parseDeleteStatement()
 
+	// ===============================
+	// Control flow
+	// ===============================
+	case atom.I:
+		node, err = p.parseIfStatement()
+	case atom.Rt:
+		node, err = p.parseLoopStatement()
+	case atom.Br:
+		node, err = p.parseBreakStatement()
+	case atom.Rb:
+		node, err = p.parseContinueStatement()
+	case atom.A:
+		node, err = p.parseJumpStatement()
+
 	// ===============================
 	// Variables
 	// ===============================
@@ -134,16 +268,53 @@ func (p *Parser) parseStatement() (ast.Node, error) {
 	case atom.Cite:
 		node, err = p.parseGetVariableStatement()
 
+	// ===============================
+	// Properties
+	// ===============================
+	case atom.Rp:
+		node, err = p.parseGetPropertyStatement()
+	case atom.Samp:
+		node, err = p.parseSetPropertyStatement()
+	case atom.Address:
+		node, err = p.parseGetDynamicPropertyStatement()
+	case atom.Ins:
+		node, err = p.parseSetDynamicPropertyStatement()
+
+	// ===============================
+	// Functions
+	// ===============================
+	case atom.Dfn:
+		node, err = p.parseFunctionStatement()
+	case atom.Code:
+		node, err = p.parseCallStatement()
+
 	// ===============================
 	// I/O
 	// ===============================
 	case atom.Output:
 		node, err = p.parsePrintStatement()
+	case atom.Input:
+		node, err = p.parseInputStatement()
+	case atom.Wbr:
+		node, err = p.parseBreakpointStatement()
+	// ===============================
+	// Programs
+	// ===============================
+	case atom.Main:
+		err = errs.NewParseError("<main> element must be a direct child of <body>")
 	default:
 		err = errs.NewParseError("unknown tag '%v'", p.curNode.Data)
 	}
 
-	return node, err
+	if err != nil {
+		return node, p.errorAt(p.curNode, err)
+	}
+
+	node.Info().ID = attrMap(p.curNode)["id"]
+	node.Info().Pos = p.positions[p.curNode]
+	node.Info().Layout = layout
+
+	return node, nil
 }
 
 func (p *Parser) parseStringStatement() (*ast.StringStatement, error) {
@@ -177,10 +348,11 @@ func (p *Parser) parseNumberStatement() (*ast.NumberStatement, error) {
 func (p *Parser) parseArrayStatement() (*ast.ArrayStatement, error) {
 	array := &ast.ArrayStatement{Elements: []*ast.ArrayElementStatement{}}
 
-	statements, err := p.parseChildStatements(expectAtom(atom.Li))
+	statements, end, err := p.parseChildStatements(expectAtom(atom.Li))
 	if err != nil {
 		return nil, err
 	}
+	array.EndLayout = end
 
 	elemStatements := sliceutil.Map(statements,
 		func(node ast.Node) *ast.ArrayElementStatement {
@@ -195,21 +367,184 @@ func (p *Parser) parseArrayStatement() (*ast.ArrayStatement, error) {
 func (p *Parser) parseArrayElementStatement() (*ast.ArrayElementStatement, error) {
 	arrayElement := &ast.ArrayElementStatement{Statements: []ast.Node{}}
 
-	statements, err := p.parseChildStatements()
+	statements, end, err := p.parseBlock(p.curNode.FirstChild, nil)
 	if err != nil {
 		return nil, err
 	}
 	arrayElement.Statements = statements
+	arrayElement.EndLayout = end
 
 	return arrayElement, nil
 }
 
+func (p *Parser) parseTableStatement() (*ast.TableStatement, error) {
+	table := &ast.TableStatement{Rows: []*ast.TableRowStatement{}}
+
+	// The HTML parser implicitly wraps table rows inside a <tbody>
+	parent := p.curNode
+	leading := ast.Layout{}
+	if child := firstElementChild(parent); child != nil && child.DataAtom == atom.Tbody {
+		for node := parent.FirstChild; node != child; node = node.NextSibling {
+			addLayout(&leading, node)
+		}
+		parent = child
+	}
+
+	statements, end, err := p.parseChildStatementsOf(parent, expectAtom(atom.Tr))
+	if err != nil {
+		return nil, err
+	}
+
+	// Comments before the <tbody> come before the first row
+	if len(statements) > 0 {
+		first := statements[0].Info()
+		first.Layout = joinLayouts(leading, first.Layout)
+	} else {
+		end = joinLayouts(leading, end)
+	}
+	table.EndLayout = end
+
+	rowStatements := sliceutil.Map(statements,
+		func(node ast.Node) *ast.TableRowStatement {
+			return node.(*ast.TableRowStatement)
+		})
+
+	table.Rows = rowStatements
+
+	return table, nil
+}
+
+func (p *Parser) parseTableRowStatement() (*ast.TableRowStatement, error) {
+	row := &ast.TableRowStatement{Statements: []ast.Node{}}
+
+	header := firstElementChild(p.curNode)
+	if header == nil || header.DataAtom != atom.Th {
+		return nil, errs.NewParseError("<tr> element has no <th> key element")
+	}
+	if header.FirstChild == nil {
+		return nil, errs.NewParseError("<th> element has no text child element")
+	}
+	row.Key = header.FirstChild.Data
+
+	cell := nextElementSibling(header)
+	if cell == nil || cell.DataAtom != atom.Td {
+		return nil, errs.NewParseError("<tr> element has no <td> value element")
+	}
+	if nextElementSibling(cell) != nil {
+		return nil, errs.NewParseError("<tr> element has more than one <td> value element")
+	}
+
+	statements, end, err := p.parseBlock(cell.FirstChild, nil)
+	if err != nil {
+		return nil, err
+	}
+	row.Statements = statements
+	row.EndLayout = end
+
+	return row, nil
+}
+
 func (p *Parser) parseBinaryOpStatement(binaryOp ast.BinaryOp) (*ast.BinaryOpStatement, error) {
 	return &ast.BinaryOpStatement{
 		Op: binaryOp,
 	}, nil
 }
 
+func (p *Parser) parseNotStatement() (*ast.NotStatement, error) {
+	return &ast.NotStatement{}, nil
+}
+
+func (p *Parser) parseIfStatement() (*ast.IfStatement, error) {
+	ifStatement := &ast.IfStatement{Consequence: []ast.Node{}}
+
+	// An <hr> element separates the consequence from the alternative
+	var separator *html.Node
+	for child := firstElementChild(p.curNode); child != nil; child = nextElementSibling(child) {
+		if child.DataAtom != atom.Hr {
+			continue
+		}
+		if separator != nil {
+			return nil, errs.NewParseError("<i> element has more than one <hr> separator")
+		}
+		separator = child
+	}
+
+	consequence, end, err := p.parseBlock(p.curNode.FirstChild, separator)
+	if err != nil {
+		return nil, err
+	}
+	ifStatement.Consequence = consequence
+
+	if separator != nil {
+		ifStatement.SeparatorLayout = end
+
+		alternative, end, err := p.parseBlock(separator.NextSibling, nil)
+		if err != nil {
+			return nil, err
+		}
+		ifStatement.Alternative = alternative
+		ifStatement.EndLayout = end
+	} else {
+		ifStatement.EndLayout = end
+	}
+
+	return ifStatement, nil
+}
+
+func (p *Parser) parseLoopStatement() (*ast.LoopStatement, error) {
+	loop := &ast.LoopStatement{Statements: []ast.Node{}}
+
+	p.loopDepth++
+	statements, end, err := p.parseBlock(p.curNode.FirstChild, nil)
+	p.loopDepth--
+	if err != nil {
+		return nil, err
+	}
+	loop.Statements = statements
+	loop.EndLayout = end
+
+	return loop, nil
+}
+
+func (p *Parser) parseBreakStatement() (*ast.BreakStatement, error) {
+	if p.loopDepth == 0 {
+		return nil, errs.NewParseError("<br> element is not inside an <rt> loop")
+	}
+	return &ast.BreakStatement{}, nil
+}
+
+func (p *Parser) parseContinueStatement() (*ast.ContinueStatement, error) {
+	if p.loopDepth == 0 {
+		return nil, errs.NewParseError("<rb> element is not inside an <rt> loop")
+	}
+	return &ast.ContinueStatement{}, nil
+}
+
+func (p *Parser) parseJumpStatement() (*ast.JumpStatement, error) {
+	attrs := attrMap(p.curNode)
+
+	href, ok := attrs["href"]
+	if !ok {
+		return nil, errs.NewParseError("attribute 'href' not found")
+	}
+
+	label, ok := strings.CutPrefix(href, "#")
+	if !ok || label == "" {
+		return nil, errs.NewParseError("href '%v' is not a label of the form '#id'", href)
+	}
+
+	if !p.labelIsVisible(label) {
+		return nil, errs.NewParseError("label '%v' is not defined in this block or an enclosing block", label)
+	}
+
+	_, conditional := attrs["data-if"]
+
+	return &ast.JumpStatement{
+		Label:       label,
+		Conditional: conditional,
+	}, nil
+}
+
 func (p *Parser) parseDuplicateStatement() (*ast.DuplicateStatement, error) {
 	return &ast.DuplicateStatement{}, nil
 }
@@ -241,24 +576,155 @@ func (p *Parser) parseSetVariableStatement() (*ast.SetVariableStatement, error)
 	}, nil
 }
 
+func (p *Parser) parseGetPropertyStatement() (*ast.GetPropertyStatement, error) {
+	attrs := attrMap(p.curNode)
+
+	property, ok := attrs["title"]
+	if !ok {
+		return nil, errs.NewParseError("attribute 'title' not found")
+	}
+
+	return &ast.GetPropertyStatement{
+		Property: property,
+	}, nil
+}
+
+func (p *Parser) parseSetPropertyStatement() (*ast.SetPropertyStatement, error) {
+	attrs := attrMap(p.curNode)
+
+	property, ok := attrs["title"]
+	if !ok {
+		return nil, errs.NewParseError("attribute 'title' not found")
+	}
+
+	return &ast.SetPropertyStatement{
+		Property: property,
+	}, nil
+}
+
+func (p *Parser) parseGetDynamicPropertyStatement() (*ast.GetDynamicPropertyStatement, error) {
+	return &ast.GetDynamicPropertyStatement{}, nil
+}
+
+func (p *Parser) parseSetDynamicPropertyStatement() (*ast.SetDynamicPropertyStatement, error) {
+	return &ast.SetDynamicPropertyStatement{}, nil
+}
+
+func (p *Parser) parseFunctionStatement() (*ast.FunctionStatement, error) {
+	function := &ast.FunctionStatement{Statements: []ast.Node{}}
+
+	// Loops and labels outside the function body cannot be reached from inside it
+	loopDepth, labels := p.loopDepth, p.labels
+	p.loopDepth, p.labels = 0, []map[string]bool{}
+	statements, end, err := p.parseBlock(p.curNode.FirstChild, nil)
+	p.loopDepth, p.labels = loopDepth, labels
+	if err != nil {
+		return nil, err
+	}
+	function.Statements = statements
+	function.EndLayout = end
+
+	return function, nil
+}
+
+func (p *Parser) parseCallStatement() (*ast.CallStatement, error) {
+	return &ast.CallStatement{}, nil
+}
+
 func (p *Parser) parsePrintStatement() (*ast.PrintStatement, error) {
-	return &ast.PrintStatement{}, nil
+	attrs := attrMap(p.curNode)
+
+	output := &ast.PrintStatement{}
+
+	switch target := attrs["for"]; target {
+	case "", "stdout":
+	case "stderr":
+		output.Stderr = true
+	default:
+		return nil, errs.NewParseError("output target '%v' is not supported", target)
+	}
+
+	_, output.Raw = attrs["data-raw"]
+	_, output.NoNewline = attrs["data-no-newline"]
+
+	return output, nil
+}
+
+func (p *Parser) parseInputStatement() (*ast.InputStatement, error) {
+	attrs := attrMap(p.curNode)
+
+	switch inputType := attrs["type"]; inputType {
+	case "", "text":
+		return &ast.InputStatement{}, nil
+	case "number":
+		return &ast.InputStatement{Number: true}, nil
+	default:
+		return nil, errs.NewParseError("input type '%v' is not supported", inputType)
+	}
+}
+
+func (p *Parser) parseBreakpointStatement() (*ast.BreakpointStatement, error) {
+	return &ast.BreakpointStatement{}, nil
 }
 
 // parseChildStatements parses the child nodes of the current node.
-func (p *Parser) parseChildStatements(validators ...func(node *html.Node) error) ([]ast.Node, error) {
-	originalNode := p.curNode
-	p.peekNode = originalNode.FirstChild
+func (p *Parser) parseChildStatements(validators ...func(node *html.Node) error) ([]ast.Node, ast.Layout, error) {
+	return p.parseChildStatementsOf(p.curNode, validators...)
+}
+
+// parseChildStatementsOf parses the child nodes of the given node.
+//
+// The parser's position is restored after parsing.
+func (p *Parser) parseChildStatementsOf(parent *html.Node, validators ...func(node *html.Node) error) ([]ast.Node, ast.Layout, error) {
+	return p.parseSiblingStatements(parent.FirstChild, nil, validators...)
+}
+
+// parseBlock parses a list of sibling statements which can jump to each other.
+//
+// The ids of the siblings are visible as labels to every statement in the block,
+// including nested blocks.
+func (p *Parser) parseBlock(first *html.Node, stop *html.Node) ([]ast.Node, ast.Layout, error) {
+	labels := map[string]bool{}
+	for node := first; node != nil && node != stop; node = node.NextSibling {
+		if node.Type != html.ElementNode {
+			continue
+		}
+
+		id, ok := attrMap(node)["id"]
+		if !ok {
+			continue
+		}
+		if p.ids[id] {
+			return nil, ast.Layout{}, p.errorAt(node, errs.NewParseError("duplicate id '%v'", id))
+		}
+		p.ids[id] = true
+		labels[id] = true
+	}
+
+	p.labels = append(p.labels, labels)
+	defer func() { p.labels = p.labels[:len(p.labels)-1] }()
+
+	return p.parseSiblingStatements(first, stop)
+}
+
+// parseSiblingStatements parses the nodes starting from first up to,
+// but not including, stop. A nil stop parses until the last sibling.
+// It also returns the layout of the source code after the last statement.
+//
+// The parser's position is restored after parsing.
+func (p *Parser) parseSiblingStatements(first *html.Node, stop *html.Node, validators ...func(node *html.Node) error) ([]ast.Node, ast.Layout, error) {
+	originalNode, originalPeekNode, originalLayout := p.curNode, p.peekNode, p.layout
+	p.peekNode = first
 	p.nextNode()
 
 	statements := []ast.Node{}
 
 	parseErrors := []error{}
-	for p.curNode != nil {
+	for ; p.curNode != nil && p.curNode != stop; p.nextNode() {
 		hasValidationErrs := false
 		for _, validator := range validators {
 			if err := validator(p.curNode); err != nil {
-				parseErrors = append(parseErrors, err)
+				parseErrors = append(parseErrors, p.errorAt(p.curNode, err))
 				hasValidationErrs = true
 			}
 		}
@@ -272,18 +738,32 @@ func (p *Parser) parseChildStatements(validators ...func(node *html.Node) error)
 			continue
 		}
 		statements = append(statements, newNode)
-
-		p.nextNode()
 	}
 
-	p.peekNode = originalNode
-	p.nextNode()
+	end := p.layout
+	p.curNode, p.peekNode, p.layout = originalNode, originalPeekNode, originalLayout
 
 	if len(parseErrors) != 0 {
-		return nil, errors.Join(parseErrors...)
+		return nil, ast.Layout{}, errors.Join(parseErrors...)
 	}
 
-	return statements, nil
+	return statements, end, nil
+}
+
+// labelIsVisible checks if a label is defined in the current block or an enclosing block.
+func (p *Parser) labelIsVisible(label string) bool {
+	for _, labels := range p.labels {
+		if labels[label] {
+			return true
+		}
+	}
+	return false
+}
+
+// errorAt attaches the position of a node to an error.
+func (p *Parser) errorAt(node *html.Node, err error) error {
+	pos := p.positions[node]
+	return errs.At(err, pos.Line, pos.Column)
 }
 
 func (p *Parser) curNodeIs(atom atom.Atom) bool {
@@ -299,6 +779,37 @@ func expectAtom(a atom.Atom) func(node *html.Node) error {
 	}
 }
 
+// FindElement returns the first element with the given atom in a depth-first search.
+func FindElement(node *html.Node, a atom.Atom) *html.Node {
+	if node.Type == html.ElementNode && node.DataAtom == a {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := FindElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// firstElementChild returns the first child of a node that is an element.
+func firstElementChild(node *html.Node) *html.Node {
+	child := node.FirstChild
+	for child != nil && child.Type != html.ElementNode {
+		child = child.NextSibling
+	}
+	return child
+}
+
+// nextElementSibling returns the next sibling of a node that is an element.
+func nextElementSibling(node *html.Node) *html.Node {
+	sibling := node.NextSibling
+	for sibling != nil && sibling.Type != html.ElementNode {
+		sibling = sibling.NextSibling
+	}
+	return sibling
+}
+
 // attrMap creates a map from the Attr slice of an [html.Node].
 func attrMap(node *html.Node) map[string]string {
 	m := make(map[string]string)
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/angelofallars/hypo/internal/ast"
+	"golang.org/x/net/html"
+)
+
+// tagAttr is the attribute added to every start tag before the source code
+// is parsed, holding the index of the tag among the scanned tags.
+const tagAttr = "data-hypo-tag"
+
+// tagToken is a start tag found in the source code.
+type tagToken struct {
+	name string
+	pos  ast.Position
+	// nameEnd is the byte offset of the end of the tag name in the source code.
+	nameEnd int
+}
+
+// scanTags returns every start tag in the source code in order, along with their positions.
+//
+// [html.Parse] discards the positions of elements, so the source code
+// is tokenized separately to recover them.
+func scanTags(s string) []tagToken {
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+
+	tags := []tagToken{}
+	line, column := 1, 1
+	offset := 0
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return tags
+		}
+
+		// Raw must be read before TagName, which modifies the token in place
+		raw := tokenizer.Raw()
+		start := ast.Position{Line: line, Column: column}
+		startOffset := offset
+		offset += len(raw)
+
+		for _, r := range string(raw) {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		// The tag name ends at the first space, slash or closing bracket
+		nameEnd := startOffset + 1
+		for nameEnd < offset && !strings.ContainsRune(" \t\n\f\r/>", rune(s[nameEnd])) {
+			nameEnd++
+		}
+
+		name, _ := tokenizer.TagName()
+		tags = append(tags, tagToken{name: string(name), pos: start, nameEnd: nameEnd})
+	}
+}
+
+// TagNames returns the names of the start tags in the source code, in order.
+//
+// The HTML parser creates some elements, like <body>, even if the source code
+// has no tags for them, so their names are only returned if the tags are written.
+func TagNames(s string) []string {
+	tags := scanTags(s)
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.name)
+	}
+	return names
+}
+
+// markTags adds an attribute holding the index of each tag to the start tags
+// in the source code, so the elements of the parsed tree can be matched with them.
+func markTags(s string, tags []tagToken) string {
+	var b strings.Builder
+	last := 0
+	for i, tag := range tags {
+		b.WriteString(s[last:tag.nameEnd])
+		b.WriteString(" " + tagAttr + `="` + strconv.Itoa(i) + `"`)
+		last = tag.nameEnd
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// matchPositions assigns the positions of the scanned start tags to the
+// elements of an *[html.Node] tree parsed from source code marked by [markTags],
+// and removes the attributes added by it.
+//
+// Elements the HTML parser creates without a tag, like <body> and <tbody>,
+// have no position. Elements it moves, like those fostered out of a <table>,
+// keep the position of their tag.
+func matchPositions(root *html.Node, tags []tagToken) map[*html.Node]ast.Position {
+	positions := map[*html.Node]ast.Position{}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			attrs := node.Attr[:0]
+			for _, attr := range node.Attr {
+				if attr.Key != tagAttr {
+					attrs = append(attrs, attr)
+					continue
+				}
+				if _, ok := positions[node]; ok {
+					continue
+				}
+				if i, err := strconv.Atoi(attr.Val); err == nil && i >= 0 && i < len(tags) {
+					positions[node] = tags[i].pos
+				}
+			}
+			node.Attr = attrs
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return positions
+}
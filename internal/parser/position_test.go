@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// elementPositions parses source code and returns the elements of the tree
+// in document order as "name line:column", with "-" for elements without a position.
+func elementPositions(t *testing.T, source string) []string {
+	t.Helper()
+
+	tags := scanTags(source)
+	root, err := html.Parse(strings.NewReader(markTags(source, tags)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions := matchPositions(root, tags)
+
+	elements := []string{}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			for _, attr := range node.Attr {
+				if attr.Key == tagAttr {
+					t.Errorf("<%v> keeps the %v attribute", node.Data, tagAttr)
+				}
+			}
+			pos, ok := positions[node]
+			if ok {
+				elements = append(elements, node.Data+" "+pos.String())
+			} else {
+				elements = append(elements, node.Data+" -")
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return elements
+}
+
+func TestMatchPositions(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "implicit document elements",
+			source: "<s>a</s>\n<output></output>",
+			want:   []string{"html -", "head -", "body -", "s 1:1", "output 2:1"},
+		},
+		{
+			name:   "paragraph created by an end tag",
+			source: "<s>a</s></p><output></output>",
+			want:   []string{"html -", "head -", "body -", "s 1:1", "p -", "output 1:13"},
+		},
+		{
+			name:   "element fostered out of a table",
+			source: "<table><s>a</s><tr><th>k</th><td></td></tr></table><output></output>",
+			want: []string{"html -", "head -", "body -", "s 1:8", "table 1:1",
+				"tbody -", "tr 1:16", "th 1:20", "td 1:30", "output 1:52"},
+		},
+		{
+			name:   "implied column group",
+			source: "<table><col><tr><td></td></tr></table><output></output>",
+			want: []string{"html -", "head -", "body -", "table 1:1", "colgroup -", "col 1:8",
+				"tbody -", "tr 1:13", "td 1:17", "output 1:39"},
+		},
+		{
+			name:   "implied table row",
+			source: "<table><td></td></table><output></output>",
+			want:   []string{"html -", "head -", "body -", "table 1:1", "tbody -", "tr -", "td 1:8", "output 1:25"},
+		},
+		{
+			name:   "attributes and self-closing tags",
+			source: "<data value=\"1\"/><br/><var\ttitle=\"x\"></var>",
+			want:   []string{"html -", "head -", "body -", "data 1:1", "br 1:18", "var 1:23"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := elementPositions(t, tt.source)
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("positions of %q =\n%v\nwant\n%v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPositionsKeepsAttributes(t *testing.T) {
+	source := `<data value="2"></data>`
+	tags := scanTags(source)
+	root, err := html.Parse(strings.NewReader(markTags(source, tags)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchPositions(root, tags)
+
+	data := FindElement(root, atom.Data)
+	if data == nil {
+		t.Fatal("no <data> element")
+	}
+	if len(data.Attr) != 1 || data.Attr[0].Key != "value" || data.Attr[0].Val != "2" {
+		t.Errorf("attributes of <data> = %v, want only value=\"2\"", data.Attr)
+	}
+}
+
+// TestParseErrorPositions checks that errors point at their own tag
+// after elements the HTML parser creates or moves.
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		// The <p> created by the end tag has no tag of its own, so it must not
+		// take the position of the <p> after it
+		{"<s>a</s></p>\n<p></p>", "ParseError: unknown tag 'p'"},
+		{"<table><data value=\"1\"></data><tr><th>k</th><td></td></tr></table>\n<s></s>",
+			"2:1: ParseError: <s> element has no text child element"},
+		{"<s>a</s></p><s></s>", "1:13: ParseError: <s> element has no text child element"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.source)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", tt.source)
+			continue
+		}
+		if !slices.Contains(strings.Split(err.Error(), "\n"), tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.source, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/angelofallars/hypo/internal/ast"
+	"github.com/angelofallars/hypo/internal/object"
+)
+
+const (
+	debugPrompt = "(wbr) "
+	debugHelp   = `Commands:
+  stack, s     print the stack, from bottom to top
+  vars, v      print the variables
+  next, n      evaluate the current statement and break again
+  continue, c  resume execution until the next breakpoint
+  abort, q     stop execution
+  help, h      print this help`
+
+	// maxStatementLength is the maximum number of characters of a statement shown at a breakpoint.
+	maxStatementLength = 72
+)
+
+// Debugger is an interactive console that runs on <wbr> breakpoints.
+//
+// It reads commands from its own reader rather than the runtime's stdin,
+// so that it does not consume the input of the program.
+type Debugger struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewDebugger returns a new [Debugger] that reads commands from in and writes to out.
+func NewDebugger(in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{in: bufio.NewReader(in), out: out}
+}
+
+// Break starts the debug console before a statement is evaluated.
+func (d *Debugger) Break(node ast.Node, env *object.Env) object.DebugAction {
+	fmt.Fprintf(d.out, "break at %v\n", summarize(node))
+	for {
+		fmt.Fprint(d.out, debugPrompt)
+		line, err := d.in.ReadString('\n')
+		if err != nil && line == "" {
+			// Nobody is around to debug, so keep going
+			fmt.Fprintln(d.out)
+			return object.DebugContinue
+		}
+
+		switch strings.TrimSpace(line) {
+		case "stack", "s":
+			d.printStack(env)
+		case "vars", "v":
+			d.printVars(env)
+		case "next", "n":
+			return object.DebugStep
+		case "continue", "c":
+			return object.DebugContinue
+		case "abort", "q":
+			return object.DebugAbort
+		case "help", "h", "":
+			fmt.Fprintln(d.out, debugHelp)
+		default:
+			fmt.Fprintf(d.out, "unknown command '%v', type 'help' for a list of commands\n", strings.TrimSpace(line))
+		}
+	}
+}
+
+// printStack prints the stack, from bottom to top.
+func (d *Debugger) printStack(env *object.Env) {
+	values := env.Stack.Values()
+	if len(values) == 0 {
+		fmt.Fprintln(d.out, "stack is empty")
+		return
+	}
+	for i, value := range values {
+		fmt.Fprintf(d.out, "%4d  %v\n", i, value.String())
+	}
+}
+
+// printVars prints the variables visible from the current scope.
+func (d *Debugger) printVars(env *object.Env) {
+	for _, identifier := range env.Vars.Identifiers() {
+		value, _ := env.Vars.Get(identifier)
+		fmt.Fprintf(d.out, "%v = %v\n", identifier, value.String())
+	}
+}
+
+// summarize returns a single-line representation of a statement.
+func summarize(node ast.Node) string {
+	s := strings.Join(strings.Fields(node.String()), " ")
+	if runes := []rune(s); len(runes) > maxStatementLength {
+		s = string(runes[:maxStatementLength-3]) + "..."
+	}
+	return s
+}
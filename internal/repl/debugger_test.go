@@ -0,0 +1,21 @@
+package repl
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/angelofallars/hypo/internal/ast"
+)
+
+func TestSummarizeMultibyte(t *testing.T) {
+	node := &ast.StringStatement{Value: strings.Repeat("é", 100)}
+
+	got := summarize(node)
+	if !utf8.ValidString(got) {
+		t.Errorf("summarize() = %q is not valid UTF-8", got)
+	}
+	if n := utf8.RuneCountInString(got); n != maxStatementLength {
+		t.Errorf("summarize() has %v characters, want %v", n, maxStatementLength)
+	}
+}
@@ -14,21 +14,20 @@ const (
 )
 
 // Start starts the REPL environment.
-func Start() {
-	scanner := bufio.NewScanner(os.Stdin)
-	runtime := runtime.New()
+func Start(opts ...runtime.Option) {
+	// Share the reader with the runtime so <input> does not steal buffered lines
+	reader := bufio.NewReader(os.Stdin)
+	runtime := runtime.New(append(opts, runtime.WithStdin(reader))...)
 
 	fmt.Println(splash)
 	for {
 		fmt.Print(prompt)
-		scanned := scanner.Scan()
-		if !scanned {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
 			return
 		}
 
-		line := scanner.Text()
-
-		err := runtime.Eval(line)
+		err = runtime.Eval(line)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			continue
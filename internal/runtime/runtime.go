@@ -1,31 +1,237 @@
 package runtime
 
 import (
+	"bufio"
+	"context"
+	"io"
+
 	"github.com/angelofallars/hypo/internal/evaluator"
 	"github.com/angelofallars/hypo/internal/object"
 	"github.com/angelofallars/hypo/internal/parser"
+	"github.com/angelofallars/hypo/internal/vm"
 )
 
 type Runtime struct {
-	env *object.Env
+	env    *object.Env
+	engine Engine
+}
+
+// Engine is the backend that runs programs.
+type Engine string
+
+const (
+	// EngineTree runs programs by walking the AST.
+	EngineTree Engine = "tree"
+	// EngineVM compiles programs into bytecode and runs them in a VM.
+	EngineVM Engine = "vm"
+)
+
+// Option configures a [Runtime].
+type Option func(*Runtime)
+
+// WithTruthiness allows values of any type to be used where a Bool is expected,
+// instead of raising a TypeError.
+func WithTruthiness() Option {
+	return func(r *Runtime) {
+		r.env.Options.Truthiness = true
+	}
+}
+
+// WithMaxIterations limits the number of iterations a single loop can run.
+// A limit of zero means loops can run forever.
+func WithMaxIterations(limit int) Option {
+	return func(r *Runtime) {
+		r.env.Options.MaxIterations = limit
+	}
+}
+
+// WithMaxCallDepth limits the number of nested function calls.
+// A limit of zero means functions can recurse until the process runs out of memory.
+func WithMaxCallDepth(limit int) Option {
+	return func(r *Runtime) {
+		r.env.Options.MaxCallDepth = limit
+	}
+}
+
+// WithMaxStatements limits the number of statements a single evaluation can execute.
+// A limit of zero means there is no limit.
+func WithMaxStatements(limit int) Option {
+	return func(r *Runtime) {
+		r.env.Options.MaxStatements = limit
+	}
+}
+
+// WithMaxStackDepth limits the number of values in the stack.
+// A limit of zero means there is no limit.
+func WithMaxStackDepth(limit int) Option {
+	return func(r *Runtime) {
+		r.env.Options.MaxStackDepth = limit
+	}
+}
+
+// WithMaxVariables limits the number of variables in a single scope.
+// A limit of zero means there is no limit.
+func WithMaxVariables(limit int) Option {
+	return func(r *Runtime) {
+		r.env.Options.MaxVariables = limit
+	}
+}
+
+// WithMaxValueSize limits the length of strings in bytes, and of arrays and objects
+// in elements, counting the elements of the values nested in them.
+// A limit of zero means there is no limit.
+func WithMaxValueSize(limit int) Option {
+	return func(r *Runtime) {
+		r.env.Options.MaxValueSize = limit
+	}
+}
+
+// WithCapabilities limits the operations with side effects programs can perform.
+// By default, every operation is allowed.
+func WithCapabilities(capabilities object.Capabilities) Option {
+	return func(r *Runtime) {
+		r.env.Options.Capabilities = capabilities
+	}
+}
+
+// WithSandbox only allows programs to write output,
+// as with [object.SandboxCapabilities].
+func WithSandbox() Option {
+	return WithCapabilities(object.SandboxCapabilities)
+}
+
+// WithStdin makes <input> read lines from a reader instead of [os.Stdin].
+func WithStdin(stdin io.Reader) Option {
+	return func(r *Runtime) {
+		r.env.Stdin = bufio.NewReader(stdin)
+	}
 }
 
-func New() *Runtime {
-	return &Runtime{
-		env: object.NewEnv(),
+// WithDebugger makes <wbr> breakpoints pause execution and hand control to a debugger.
+// Without a debugger, breakpoints are ignored.
+func WithDebugger(debugger object.Debugger) Option {
+	return func(r *Runtime) {
+		r.env.Debugger = debugger
 	}
 }
 
+// WithStdout makes <output> write to a writer instead of [os.Stdout].
+func WithStdout(stdout io.Writer) Option {
+	return func(r *Runtime) {
+		r.env.Stdout = stdout
+	}
+}
+
+// WithRawOutput makes <output> print strings without quotes.
+func WithRawOutput() Option {
+	return func(r *Runtime) {
+		r.env.Options.RawOutput = true
+	}
+}
+
+// WithStderr makes <output for="stderr"> write to a writer instead of [os.Stderr].
+func WithStderr(stderr io.Writer) Option {
+	return func(r *Runtime) {
+		r.env.Stderr = stderr
+	}
+}
+
+// WithEngine selects the backend that runs programs. The default is [EngineTree].
+func WithEngine(engine Engine) Option {
+	return func(r *Runtime) {
+		r.engine = engine
+	}
+}
+
+func New(opts ...Option) *Runtime {
+	runtime := &Runtime{
+		env:    object.NewEnv(),
+		engine: EngineTree,
+	}
+	for _, opt := range opts {
+		opt(runtime)
+	}
+	return runtime
+}
+
+// Env returns the environment that holds the runtime's values.
+func (i *Runtime) Env() *object.Env {
+	return i.env
+}
+
+// Func describes a host function to register.
+type Func struct {
+	Name string
+	// Params are the types of the arguments, from the bottom of the stack to the top.
+	// Use [object.AnyType] to accept any type.
+	Params []object.ObjectType
+	Fn     object.HostFunc
+	// Files marks functions that access the file system,
+	// which can only be called if file access is allowed.
+	Files bool
+}
+
+// RegisterFunc defines a variable holding a function implemented in Go,
+// which programs can call with <code>.
+//
+// The function takes one argument for each of the parameter types,
+// which are checked before the function is called.
+func (i *Runtime) RegisterFunc(name string, fn object.HostFunc, params ...object.ObjectType) {
+	_ = i.env.Vars.Set(name, &object.HostFunction{
+		Name:   name,
+		Params: params,
+		Fn:     fn,
+	})
+}
+
+// RegisterModule defines a variable holding an object whose properties
+// are functions implemented in Go, which programs can get with <rp>.
+func (i *Runtime) RegisterModule(name string, funcs ...Func) {
+	module := object.NewObj()
+	for _, fn := range funcs {
+		module.Set(fn.Name, &object.HostFunction{
+			Name:   name + "." + fn.Name,
+			Params: fn.Params,
+			Fn:     fn.Fn,
+			Files:  fn.Files,
+		})
+	}
+	_ = i.env.Vars.Set(name, module)
+}
+
 // Eval executes HTML, the programming language code from a string.
 //
 // State, like the stack and variable list, is maintained between Eval calls to the same [Runtime] instance.
 func (i *Runtime) Eval(s string) error {
+	return i.EvalContext(context.Background(), s)
+}
+
+// EvalContext is like [Runtime.Eval], but stops execution with a CancelError
+// once the context is canceled. Reading from stdin is not interrupted.
+func (i *Runtime) EvalContext(ctx context.Context, s string) error {
 	program, err := parser.Parse(s)
 	if err != nil {
 		return err
 	}
 
-	err = evaluator.Exec(program, i.env)
+	i.env.Context = ctx
+	i.env.Statements = 0
+	defer func() {
+		i.env.Context = context.Background()
+		// Stepping through one program does not carry over to the next
+		i.env.Stepping = false
+	}()
+
+	if err := evaluator.CheckCanceled(i.env); err != nil {
+		return err
+	}
+
+	switch i.engine {
+	case EngineVM:
+		err = vm.Run(program, i.env)
+	default:
+		err = evaluator.Exec(program, i.env)
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/angelofallars/hypo/internal/ast"
+	errs "github.com/angelofallars/hypo/internal/errors"
+	"github.com/angelofallars/hypo/internal/object"
+)
+
+// stepper steps through every statement, counting the breaks.
+type stepper struct {
+	breaks int
+}
+
+func (s *stepper) Break(node ast.Node, env *object.Env) object.DebugAction {
+	s.breaks++
+	return object.DebugStep
+}
+
+// TestSteppingEndsWithEval checks that stepping through a program that fails
+// does not make the debugger break in the programs evaluated after it.
+func TestSteppingEndsWithEval(t *testing.T) {
+	for _, engine := range []Engine{EngineTree, EngineVM} {
+		t.Run(string(engine), func(t *testing.T) {
+			debugger := &stepper{}
+			r := New(WithEngine(engine), WithDebugger(debugger), WithStdout(io.Discard))
+
+			if err := r.Eval(`<wbr><output></output>`); err == nil {
+				t.Fatal("expected a stack error")
+			}
+			if r.Env().Stepping {
+				t.Error("still stepping after the evaluation failed")
+			}
+
+			debugger.breaks = 0
+			if err := r.Eval(`<data value="1"></data><output></output>`); err != nil {
+				t.Fatal(err)
+			}
+			if debugger.breaks != 0 {
+				t.Errorf("debugger broke %v times in a program without breakpoints", debugger.breaks)
+			}
+		})
+	}
+}
+
+// TestStackDepthAfterHostCall checks that a host function returning more values
+// than the stack can hold fails at the call, even as the last statement.
+func TestStackDepthAfterHostCall(t *testing.T) {
+	for _, engine := range []Engine{EngineTree, EngineVM} {
+		t.Run(string(engine), func(t *testing.T) {
+			r := New(WithEngine(engine), WithMaxStackDepth(2))
+			r.RegisterFunc("three", func(args []object.Object) ([]object.Object, error) {
+				return []object.Object{object.NewNumber(1), object.NewNumber(2), object.NewNumber(3)}, nil
+			})
+
+			err := r.Eval(`<cite>three</cite><code></code>`)
+			if !errors.Is(err, errs.ErrStackOverflow) {
+				t.Fatalf("error = %v, want a StackOverflowError", err)
+			}
+			if !strings.HasPrefix(err.Error(), "1:19:") {
+				t.Errorf("error = %v, want it at the call on 1:19", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+// package vm provides a bytecode compiler for [ast.Program]s,
+// and a stack-based virtual machine that runs the bytecode.
+//
+// The VM behaves identically to the tree-walking evaluator, which it
+// falls back on for statements that do not benefit from compilation.
+package vm
+
+import (
+	"fmt"
+
+	"github.com/angelofallars/hypo/internal/ast"
+	"github.com/angelofallars/hypo/internal/object"
+)
+
+// Opcode is the operation performed by an [Instruction].
+type Opcode uint8
+
+const (
+	// OpConst pushes Consts[Arg].
+	OpConst Opcode = iota
+	// OpExec evaluates the instruction's node with the tree-walking evaluator.
+	OpExec
+	// OpGetVar pushes the variable named Names[Arg].
+	OpGetVar
+	// OpSetVar pops a value into the variable named Names[Arg].
+	OpSetVar
+	// OpDup duplicates the top value.
+	OpDup
+	// OpDel pops the top value.
+	OpDel
+	// OpBinary performs the instruction's binary operation.
+	OpBinary
+	// OpGoto moves to the instruction at Arg.
+	OpGoto
+	// OpJump moves to the target Jumps[Arg], leaving any loops
+	// and literals in between.
+	OpJump
+	// OpJumpIfTrue pops a condition and performs OpJump if it is true.
+	OpJumpIfTrue
+	// OpJumpIfFalse pops a condition and moves to the instruction at Arg if it is false.
+	OpJumpIfFalse
+	// OpLoopEnter starts counting the iterations of a loop.
+	OpLoopEnter
+	// OpLoopTest pops a condition and moves to the instruction at Arg if it is false.
+	OpLoopTest
+	// OpLoopExit stops counting the iterations of the innermost loop.
+	OpLoopExit
+	// OpBeginArray starts building an array.
+	OpBeginArray
+	// OpArrayElement pops a value into the array being built.
+	OpArrayElement
+	// OpEndArray pushes the array being built.
+	OpEndArray
+	// OpBeginTable starts building an object.
+	OpBeginTable
+	// OpTableEntry pops a value into the object being built, under the key Names[Arg].
+	OpTableEntry
+	// OpEndTable pushes the object being built.
+	OpEndTable
+	// OpMakeFunction pushes a function with the code Functions[Arg].
+	OpMakeFunction
+	// OpCall pops a function and calls it.
+	OpCall
+	// OpReturn returns from the current function.
+	OpReturn
+)
+
+// Instruction is a single operation in a [Chunk].
+type Instruction struct {
+	Op  Opcode
+	Arg int
+	// Node is the statement the instruction was compiled from.
+	Node ast.Node
+	// Stmt marks the first instruction of a statement in a block,
+	// where statements are counted and the debugger breaks while stepping.
+	Stmt bool
+}
+
+// Jump is the target of an OpJump.
+type Jump struct {
+	IP int
+	// Loops and Builders are how many loops and array or object literals
+	// enclose the target, relative to the function.
+	Loops    int
+	Builders int
+}
+
+// Chunk is a compiled program or function body.
+type Chunk struct {
+	Code      []Instruction
+	Consts    []object.Object
+	Names     []string
+	Jumps     []Jump
+	Functions []*Chunk
+}
+
+// block is a list of statements that can jump to each other.
+type block struct {
+	labels map[string]bool
+	starts map[string]int
+	// pending holds the Jumps indices of jumps to each label.
+	pending  map[string][]int
+	loops    int
+	builders int
+}
+
+// loop holds the jumps of a loop being compiled.
+type loop struct {
+	head int
+	// breaks holds the Jumps indices of <br> statements.
+	breaks   []int
+	loops    int
+	builders int
+}
+
+type compiler struct {
+	chunk    *Chunk
+	names    map[string]int
+	loops    int
+	builders int
+	blocks   []*block
+	loopJmps []*loop
+}
+
+// Compile compiles a program into bytecode.
+func Compile(program *ast.Program) *Chunk {
+	return compileBody(program.Statements, false)
+}
+
+// compileBody compiles a program or function body into a new [Chunk].
+func compileBody(statements []ast.Node, isFunction bool) *Chunk {
+	c := &compiler{
+		chunk: &Chunk{},
+		names: map[string]int{},
+	}
+	c.compileBlock(statements)
+	if isFunction {
+		c.emit(OpReturn, 0, nil)
+	}
+	return c.chunk
+}
+
+func (c *compiler) compileBlock(statements []ast.Node) {
+	b := &block{
+		labels:   map[string]bool{},
+		starts:   map[string]int{},
+		pending:  map[string][]int{},
+		loops:    c.loops,
+		builders: c.builders,
+	}
+	for _, statement := range statements {
+		if id := statement.Info().ID; id != "" {
+			b.labels[id] = true
+		}
+	}
+
+	c.blocks = append(c.blocks, b)
+	for _, statement := range statements {
+		start := len(c.chunk.Code)
+		if id := statement.Info().ID; id != "" {
+			b.starts[id] = start
+		}
+
+		c.compileStatement(statement)
+
+		if _, ok := statement.(*ast.BreakpointStatement); !ok && start < len(c.chunk.Code) {
+			c.chunk.Code[start].Stmt = true
+		}
+	}
+	c.blocks = c.blocks[:len(c.blocks)-1]
+
+	for label, jumps := range b.pending {
+		for _, jump := range jumps {
+			c.chunk.Jumps[jump].IP = b.starts[label]
+		}
+	}
+}
+
+func (c *compiler) compileStatement(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.StringStatement:
+		c.emit(OpConst, c.addConst(&object.String{Value: node.Value}), node)
+	case *ast.NumberStatement:
+		c.emit(OpConst, c.addConst(object.NewNumber(node.Value)), node)
+	case *ast.BoolStatement:
+		c.emit(OpConst, c.addConst(object.NewBool(node.Value)), node)
+	case *ast.ArrayStatement:
+		c.emit(OpBeginArray, 0, node)
+		c.builders++
+		for _, element := range node.Elements {
+			c.compileBlock(element.Statements)
+			c.emit(OpArrayElement, 0, node)
+		}
+		c.builders--
+		c.emit(OpEndArray, 0, node)
+	case *ast.TableStatement:
+		c.emit(OpBeginTable, 0, node)
+		c.builders++
+		for _, row := range node.Rows {
+			c.compileBlock(row.Statements)
+			c.emit(OpTableEntry, c.addName(row.Key), node)
+		}
+		c.builders--
+		c.emit(OpEndTable, 0, node)
+
+	case *ast.BinaryOpStatement:
+		c.emit(OpBinary, 0, node)
+	case *ast.DuplicateStatement:
+		c.emit(OpDup, 0, node)
+	case *ast.DeleteStatement:
+		c.emit(OpDel, 0, node)
+	case *ast.GetVariableStatement:
+		c.emit(OpGetVar, c.addName(node.Identifier), node)
+	case *ast.SetVariableStatement:
+		c.emit(OpSetVar, c.addName(node.Identifier), node)
+
+	case *ast.IfStatement:
+		jumpToAlternative := c.emit(OpJumpIfFalse, 0, node)
+		c.compileBlock(node.Consequence)
+		jumpToEnd := c.emit(OpGoto, 0, node)
+		c.patch(jumpToAlternative)
+		c.compileBlock(node.Alternative)
+		c.patch(jumpToEnd)
+	case *ast.LoopStatement:
+		c.compileLoop(node)
+	case *ast.BreakStatement:
+		l := c.loopJmps[len(c.loopJmps)-1]
+		jump := c.addJump(Jump{Loops: l.loops, Builders: l.builders})
+		l.breaks = append(l.breaks, jump)
+		c.emit(OpJump, jump, node)
+	case *ast.ContinueStatement:
+		l := c.loopJmps[len(c.loopJmps)-1]
+		c.emit(OpJump, c.addJump(Jump{IP: l.head, Loops: l.loops, Builders: l.builders}), node)
+	case *ast.JumpStatement:
+		c.compileJump(node)
+
+	case *ast.FunctionStatement:
+		c.chunk.Functions = append(c.chunk.Functions, compileBody(node.Statements, true))
+		c.emit(OpMakeFunction, len(c.chunk.Functions)-1, node)
+	case *ast.CallStatement:
+		c.emit(OpCall, 0, node)
+
+	default:
+		c.emit(OpExec, 0, node)
+	}
+}
+
+func (c *compiler) compileLoop(node *ast.LoopStatement) {
+	c.emit(OpLoopEnter, 0, node)
+	c.loops++
+
+	l := &loop{head: len(c.chunk.Code), loops: c.loops, builders: c.builders}
+	exit := c.emit(OpLoopTest, 0, node)
+
+	c.loopJmps = append(c.loopJmps, l)
+	c.compileBlock(node.Statements)
+	c.loopJmps = c.loopJmps[:len(c.loopJmps)-1]
+
+	c.emit(OpGoto, l.head, node)
+	c.patch(exit)
+	for _, jump := range l.breaks {
+		c.chunk.Jumps[jump].IP = len(c.chunk.Code)
+	}
+
+	c.loops--
+	c.emit(OpLoopExit, 0, node)
+}
+
+func (c *compiler) compileJump(node *ast.JumpStatement) {
+	op := OpJump
+	if node.Conditional {
+		op = OpJumpIfTrue
+	}
+
+	// The parser guarantees the label is in this block or an enclosing one
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		b := c.blocks[i]
+		if !b.labels[node.Label] {
+			continue
+		}
+
+		jump := c.addJump(Jump{Loops: b.loops, Builders: b.builders})
+		b.pending[node.Label] = append(b.pending[node.Label], jump)
+		c.emit(op, jump, node)
+		return
+	}
+
+	panic(fmt.Sprintf("vm: label '%v' is not defined", node.Label))
+}
+
+// emit appends an instruction to the chunk and returns its index.
+func (c *compiler) emit(op Opcode, arg int, node ast.Node) int {
+	c.chunk.Code = append(c.chunk.Code, Instruction{Op: op, Arg: arg, Node: node})
+	return len(c.chunk.Code) - 1
+}
+
+// patch makes the instruction at the index move to the next instruction to be emitted.
+func (c *compiler) patch(index int) {
+	c.chunk.Code[index].Arg = len(c.chunk.Code)
+}
+
+func (c *compiler) addConst(obj object.Object) int {
+	c.chunk.Consts = append(c.chunk.Consts, obj)
+	return len(c.chunk.Consts) - 1
+}
+
+func (c *compiler) addName(name string) int {
+	if index, ok := c.names[name]; ok {
+		return index
+	}
+	c.chunk.Names = append(c.chunk.Names, name)
+	c.names[name] = len(c.chunk.Names) - 1
+	return c.names[name]
+}
+
+func (c *compiler) addJump(jump Jump) int {
+	c.chunk.Jumps = append(c.chunk.Jumps, jump)
+	return len(c.chunk.Jumps) - 1
+}
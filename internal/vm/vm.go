@@ -0,0 +1,362 @@
+package vm
+
+import (
+	"cmp"
+
+	"github.com/angelofallars/hypo/internal/ast"
+	errs "github.com/angelofallars/hypo/internal/errors"
+	"github.com/angelofallars/hypo/internal/evaluator"
+	"github.com/angelofallars/hypo/internal/object"
+)
+
+// frame is a function call being run.
+type frame struct {
+	chunk *Chunk
+	ip    int
+	// callerVars is the variable scope to restore when returning.
+	callerVars *object.Scope
+	// loopBase and builderBase are the number of loops and builders
+	// that were active when the function was called.
+	loopBase    int
+	builderBase int
+}
+
+// builder is an array or object literal being built.
+type builder struct {
+	// initialLength is the length of the stack when the literal started.
+	initialLength int
+	elements      []object.Object
+	obj           *object.Obj
+}
+
+// VM runs compiled bytecode.
+type VM struct {
+	env    *object.Env
+	frames []frame
+	// loops holds the iteration counts of the active loops.
+	loops    []int
+	builders []builder
+}
+
+// Run compiles and runs a program.
+func Run(program *ast.Program, env *object.Env) error {
+	return New(env).Run(Compile(program))
+}
+
+// New returns a new [VM] that runs code in an environment.
+func New(env *object.Env) *VM {
+	return &VM{env: env}
+}
+
+// Run runs a compiled program.
+func (vm *VM) Run(chunk *Chunk) error {
+	vm.frames = append(vm.frames[:0], frame{chunk: chunk})
+	vm.loops = vm.loops[:0]
+	vm.builders = vm.builders[:0]
+
+	err := vm.run()
+	if err != nil {
+		vm.unwind()
+	}
+	return err
+}
+
+func (vm *VM) run() error {
+	env := vm.env
+
+	for {
+		f := &vm.frames[len(vm.frames)-1]
+		if f.ip >= len(f.chunk.Code) {
+			return nil
+		}
+
+		inst := &f.chunk.Code[f.ip]
+		f.ip++
+
+		if inst.Stmt {
+			if err := evaluator.CountStatement(env); err != nil {
+				return vm.errorAt(inst, err)
+			}
+			if env.Stepping {
+				if err := evaluator.DebugBreak(inst.Node, env); err != nil {
+					return vm.errorAt(inst, err)
+				}
+			}
+		}
+
+		var err error
+		switch inst.Op {
+		case OpConst:
+			env.Stack.Push(f.chunk.Consts[inst.Arg])
+		case OpExec:
+			err = evaluator.Exec(inst.Node, env)
+		case OpGetVar:
+			var obj object.Object
+			obj, err = env.Vars.Get(f.chunk.Names[inst.Arg])
+			if err == nil {
+				env.Stack.Push(obj)
+			}
+		case OpSetVar:
+			var obj object.Object
+			obj, err = env.Stack.Pop()
+			if err == nil {
+				err = evaluator.SetVariable(f.chunk.Names[inst.Arg], obj, env)
+			}
+		case OpDup:
+			var obj object.Object
+			obj, err = env.Stack.Peek()
+			if err == nil {
+				env.Stack.Push(obj)
+			}
+		case OpDel:
+			_, err = env.Stack.Pop()
+		case OpBinary:
+			err = vm.binary(inst.Node.(*ast.BinaryOpStatement))
+
+		case OpGoto:
+			f.ip = inst.Arg
+		case OpJump:
+			vm.jump(f, f.chunk.Jumps[inst.Arg])
+		case OpJumpIfTrue:
+			var condition bool
+			condition, err = vm.popCondition("conditional jump")
+			if err == nil && condition {
+				vm.jump(f, f.chunk.Jumps[inst.Arg])
+			}
+		case OpJumpIfFalse:
+			var condition bool
+			condition, err = vm.popCondition("conditional")
+			if err == nil && !condition {
+				f.ip = inst.Arg
+			}
+		case OpLoopEnter:
+			vm.loops = append(vm.loops, 0)
+		case OpLoopTest:
+			var condition bool
+			condition, err = vm.popCondition("loop")
+			if err != nil {
+				break
+			}
+			if !condition {
+				f.ip = inst.Arg
+				break
+			}
+
+			iterations := &vm.loops[len(vm.loops)-1]
+			if limit := env.Options.MaxIterations; limit > 0 && *iterations >= limit {
+				err = errs.NewLoopError("loop exceeded the maximum of %v iterations", limit)
+				break
+			}
+			*iterations++
+		case OpLoopExit:
+			vm.loops = vm.loops[:len(vm.loops)-1]
+
+		case OpBeginArray:
+			vm.builders = append(vm.builders, builder{
+				initialLength: env.Stack.Len(),
+				elements:      []object.Object{},
+			})
+		case OpArrayElement:
+			b := &vm.builders[len(vm.builders)-1]
+			var obj object.Object
+			obj, err = vm.popElement(b)
+			if err == nil {
+				b.elements = append(b.elements, obj)
+			}
+		case OpEndArray:
+			b := vm.builders[len(vm.builders)-1]
+			vm.builders = vm.builders[:len(vm.builders)-1]
+			array := &object.Array{Value: b.elements}
+			if err = evaluator.CheckSize(array, env); err == nil {
+				env.Stack.Push(array)
+			}
+		case OpBeginTable:
+			vm.builders = append(vm.builders, builder{
+				initialLength: env.Stack.Len(),
+				obj:           object.NewObj(),
+			})
+		case OpTableEntry:
+			b := &vm.builders[len(vm.builders)-1]
+			var obj object.Object
+			obj, err = vm.popElement(b)
+			if err == nil {
+				b.obj.Set(f.chunk.Names[inst.Arg], obj)
+			}
+		case OpEndTable:
+			b := vm.builders[len(vm.builders)-1]
+			vm.builders = vm.builders[:len(vm.builders)-1]
+			if err = evaluator.CheckSize(b.obj, env); err == nil {
+				env.Stack.Push(b.obj)
+			}
+
+		case OpMakeFunction:
+			env.Stack.Push(&object.Function{
+				Body:  inst.Node.(*ast.FunctionStatement).Statements,
+				Scope: env.Vars,
+				Code:  f.chunk.Functions[inst.Arg],
+			})
+		case OpCall:
+			err = vm.call()
+		case OpReturn:
+			env.Vars = f.callerVars
+			env.CallDepth--
+			vm.frames = vm.frames[:len(vm.frames)-1]
+		}
+
+		if err == nil {
+			err = evaluator.CheckStackDepth(env)
+		}
+		if err != nil {
+			return vm.errorAt(inst, err)
+		}
+	}
+}
+
+// binary performs a binary operation, with a fast path for numbers.
+func (vm *VM) binary(node *ast.BinaryOpStatement) error {
+	objects, err := vm.env.Stack.PeekMany(2)
+	if err != nil {
+		return err
+	}
+
+	right, rightOk := objects[0].(*object.Number)
+	left, leftOk := objects[1].(*object.Number)
+	if !rightOk || !leftOk {
+		return evaluator.Exec(node, vm.env)
+	}
+
+	var result object.Object
+	switch node.Op {
+	case ast.BinAdd:
+		result = object.NewNumber(left.Value + right.Value)
+	case ast.BinSubtract:
+		result = object.NewNumber(left.Value - right.Value)
+	case ast.BinMultiply:
+		result = object.NewNumber(left.Value * right.Value)
+	case ast.BinDivide:
+		result = object.NewNumber(left.Value / right.Value)
+	case ast.BinGreaterThan:
+		result = object.NewBool(cmp.Compare(left.Value, right.Value) > 0)
+	case ast.BinLessThan:
+		result = object.NewBool(cmp.Compare(left.Value, right.Value) < 0)
+	case ast.BinEqual:
+		result = object.NewBool(left.Value == right.Value)
+	default:
+		return evaluator.Exec(node, vm.env)
+	}
+
+	_ = vm.env.Stack.Drop(2)
+	vm.env.Stack.Push(result)
+	return nil
+}
+
+// call pops a function off the stack and calls it.
+func (vm *VM) call() error {
+	env := vm.env
+
+	obj, err := env.Stack.Peek()
+	if err != nil {
+		return err
+	}
+
+	var function *object.Function
+	switch obj := obj.(type) {
+	case *object.Function:
+		function = obj
+	case *object.HostFunction:
+		return evaluator.CallHost(obj, env)
+	default:
+		return errs.NewTypeError("cannot call value of type '%v'", obj.Type())
+	}
+
+	if limit := env.Options.MaxCallDepth; limit > 0 && env.CallDepth >= limit {
+		return errs.NewRecursionError("exceeded the maximum call depth of %v", limit)
+	}
+	_, _ = env.Stack.Pop()
+
+	// Functions created by the tree-walking evaluator are compiled on their first call
+	chunk, ok := function.Code.(*Chunk)
+	if !ok {
+		chunk = compileBody(function.Body, true)
+		function.Code = chunk
+	}
+
+	vm.frames = append(vm.frames, frame{
+		chunk:       chunk,
+		callerVars:  env.Vars,
+		loopBase:    len(vm.loops),
+		builderBase: len(vm.builders),
+	})
+	env.Vars = function.Scope.NewScope()
+	env.CallDepth++
+	return nil
+}
+
+// jump moves to a target, leaving the loops and literals in between.
+func (vm *VM) jump(f *frame, target Jump) {
+	vm.loops = vm.loops[:f.loopBase+target.Loops]
+	vm.unwindBuilders(f.builderBase + target.Builders)
+	f.ip = target.IP
+}
+
+// popCondition pops a condition off the stack.
+func (vm *VM) popCondition(operation string) (bool, error) {
+	obj, err := vm.env.Stack.Peek()
+	if err != nil {
+		return false, err
+	}
+
+	condition, err := evaluator.ToBool(operation, obj, vm.env)
+	if err != nil {
+		return false, err
+	}
+	_, _ = vm.env.Stack.Pop()
+
+	return condition, nil
+}
+
+// popElement pops the value of an element of a literal, and any excess values
+// its statements left on the stack.
+func (vm *VM) popElement(b *builder) (object.Object, error) {
+	obj, err := vm.env.Stack.Pop()
+	if err != nil {
+		return nil, err
+	}
+
+	removeCount := vm.env.Stack.Len() - b.initialLength
+	_ = vm.env.Stack.Drop(removeCount)
+	return obj, nil
+}
+
+// unwindBuilders abandons literals until only count remain,
+// popping every value their statements left on the stack.
+func (vm *VM) unwindBuilders(count int) {
+	for len(vm.builders) > count {
+		b := vm.builders[len(vm.builders)-1]
+		vm.builders = vm.builders[:len(vm.builders)-1]
+
+		removeCount := vm.env.Stack.Len() - b.initialLength
+		_ = vm.env.Stack.Drop(removeCount)
+	}
+}
+
+// unwind restores the environment after an error.
+func (vm *VM) unwind() {
+	for len(vm.frames) > 1 {
+		f := vm.frames[len(vm.frames)-1]
+		vm.env.Vars = f.callerVars
+		vm.env.CallDepth--
+		vm.frames = vm.frames[:len(vm.frames)-1]
+	}
+	vm.loops = vm.loops[:0]
+	vm.unwindBuilders(0)
+}
+
+// errorAt attaches the position of an instruction's statement to an error.
+func (vm *VM) errorAt(inst *Instruction, err error) error {
+	if inst.Node == nil {
+		return err
+	}
+	pos := inst.Node.Info().Pos
+	return errs.At(err, pos.Line, pos.Column)
+}
@@ -0,0 +1,86 @@
+package hypo
+
+import (
+	"errors"
+
+	errs "github.com/angelofallars/hypo/internal/errors"
+)
+
+// ErrorKind is the kind of an [Error], like "TypeError".
+type ErrorKind string
+
+// Error is an error raised while parsing or running a program.
+//
+// Parsing can fail with several errors at once, which are returned
+// joined with [errors.Join].
+type Error struct {
+	err errs.Error
+}
+
+// Sentinel errors for use with [errors.Is]. An [Error] matches the sentinel of its kind.
+var (
+	ErrParse             = Error{errs.ErrParse}
+	ErrStackUnderflow    = Error{errs.ErrStackUnderflow}
+	ErrUndefinedVariable = Error{errs.ErrUndefinedVariable}
+	ErrType              = Error{errs.ErrType}
+	ErrAttribute         = Error{errs.ErrAttribute}
+	ErrLoopLimit         = Error{errs.ErrLoopLimit}
+	ErrRecursionLimit    = Error{errs.ErrRecursionLimit}
+	ErrIndex             = Error{errs.ErrIndex}
+	ErrAborted           = Error{errs.ErrAborted}
+	ErrIO                = Error{errs.ErrIO}
+	ErrHost              = Error{errs.ErrHost}
+	ErrCanceled          = Error{errs.ErrCanceled}
+	ErrStatementLimit    = Error{errs.ErrStatementLimit}
+	ErrStackOverflow     = Error{errs.ErrStackOverflow}
+	ErrVariableLimit     = Error{errs.ErrVariableLimit}
+	ErrSizeLimit         = Error{errs.ErrSizeLimit}
+	ErrPermission        = Error{errs.ErrPermission}
+)
+
+// Kind returns the kind of the error.
+func (e Error) Kind() ErrorKind { return ErrorKind(e.err.Kind()) }
+
+// Code returns the stable numeric code of the error's kind,
+// as documented for the hypo command.
+func (e Error) Code() int { return e.err.Code() }
+
+// Message returns the error message without the kind and position.
+func (e Error) Message() string { return e.err.Message() }
+
+// Position returns the line and column where the error occurred.
+// Both are zero if the position is unknown.
+func (e Error) Position() (line int, column int) { return e.err.Position() }
+
+// Unwrap returns the underlying cause of the error, if any.
+func (e Error) Unwrap() error { return e.err.Unwrap() }
+
+// Is reports whether the error matches a sentinel error of the same kind.
+func (e Error) Is(target error) bool {
+	t, ok := target.(Error)
+	return ok && e.err.Is(t.err)
+}
+
+// MarshalJSON renders the error as a JSON object with its kind, code,
+// message, position and cause. The position and cause are omitted if unknown.
+func (e Error) MarshalJSON() ([]byte, error) { return e.err.MarshalJSON() }
+
+func (e Error) Error() string { return e.err.Error() }
+
+// toError converts the errors raised by the runtime into [Error] values,
+// keeping every error of a joined error. Other errors are returned as they are.
+func toError(err error) error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		converted := []error{}
+		for _, err := range joined.Unwrap() {
+			converted = append(converted, toError(err))
+		}
+		return errors.Join(converted...)
+	}
+
+	var e errs.Error
+	if err == nil || !errors.As(err, &e) {
+		return err
+	}
+	return Error{e}
+}
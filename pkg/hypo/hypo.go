@@ -0,0 +1,287 @@
+// Package hypo embeds the Hypo runtime for HTML, the programming language in Go programs.
+//
+// The API of this package follows semantic versioning: exported identifiers
+// are not removed or changed incompatibly within a major version.
+// Packages under internal/ have no such guarantee.
+package hypo
+
+import (
+	"context"
+	"io"
+
+	"github.com/angelofallars/hypo/internal/object"
+	"github.com/angelofallars/hypo/internal/runtime"
+)
+
+// Capabilities are the operations with side effects a program can perform.
+// Performing any other operation raises an error matching [ErrPermission].
+type Capabilities struct {
+	// Stdin allows <input> to read from stdin.
+	Stdin bool
+	// Stdout allows <output> to write to stdout and stderr.
+	Stdout bool
+	// HostFunctions allows calling functions written in Go,
+	// whether they were registered on their own or in a module.
+	HostFunctions bool
+	// Files allows calling host functions that access the file system.
+	Files bool
+}
+
+var (
+	// AllCapabilities allows every operation. It is the default.
+	AllCapabilities = Capabilities{Stdin: true, Stdout: true, HostFunctions: true, Files: true}
+	// SandboxCapabilities only allows writing output.
+	SandboxCapabilities = Capabilities{Stdout: true}
+)
+
+// Runtime runs programs written in HTML, the programming language.
+//
+// State, like the stack and variables, is kept between calls to the same Runtime.
+// A Runtime is not safe for concurrent use.
+type Runtime struct {
+	runtime *runtime.Runtime
+}
+
+// Option configures a [Runtime].
+type Option func(*config)
+
+type config struct {
+	options []runtime.Option
+	vars    map[string]Value
+}
+
+// WithStdout makes <output> write to a writer instead of [os.Stdout].
+func WithStdout(w io.Writer) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithStdout(w)) }
+}
+
+// WithRawOutput makes <output> print strings without quotes.
+func WithRawOutput() Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithRawOutput()) }
+}
+
+// WithStderr makes <output for="stderr"> write to a writer instead of [os.Stderr].
+func WithStderr(w io.Writer) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithStderr(w)) }
+}
+
+// WithStdin makes <input> read lines from a reader instead of [os.Stdin].
+func WithStdin(r io.Reader) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithStdin(r)) }
+}
+
+// WithTruthiness allows values of any type to be used where a Bool is expected.
+func WithTruthiness() Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithTruthiness()) }
+}
+
+// WithMaxIterations limits the number of iterations a single loop can run.
+// A limit of zero means loops can run forever.
+func WithMaxIterations(limit int) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithMaxIterations(limit)) }
+}
+
+// WithMaxCallDepth limits the number of nested function calls.
+// A limit of zero removes the limit.
+func WithMaxCallDepth(limit int) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithMaxCallDepth(limit)) }
+}
+
+// WithMaxStatements limits the number of statements a single call to Eval can execute.
+// A limit of zero removes the limit.
+func WithMaxStatements(limit int) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithMaxStatements(limit)) }
+}
+
+// WithMaxStackDepth limits the number of values in the stack.
+// A limit of zero removes the limit.
+func WithMaxStackDepth(limit int) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithMaxStackDepth(limit)) }
+}
+
+// WithMaxVariables limits the number of variables in a single scope.
+// A limit of zero removes the limit.
+func WithMaxVariables(limit int) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithMaxVariables(limit)) }
+}
+
+// WithMaxValueSize limits the length of strings in bytes, and of arrays and objects
+// in elements, counting the elements of the values nested in them.
+// A limit of zero removes the limit.
+func WithMaxValueSize(limit int) Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithMaxValueSize(limit)) }
+}
+
+// WithCapabilities limits the operations with side effects programs can perform.
+func WithCapabilities(capabilities Capabilities) Option {
+	return func(c *config) {
+		c.options = append(c.options, runtime.WithCapabilities(object.Capabilities{
+			Stdin:         capabilities.Stdin,
+			Stdout:        capabilities.Stdout,
+			HostFunctions: capabilities.HostFunctions,
+			Files:         capabilities.Files,
+		}))
+	}
+}
+
+// WithSandbox only allows programs to write output, as with [SandboxCapabilities].
+func WithSandbox() Option {
+	return func(c *config) { c.options = append(c.options, runtime.WithSandbox()) }
+}
+
+// WithVar defines a variable before any program runs.
+func WithVar(name string, value Value) Option {
+	return func(c *config) { c.vars[name] = value }
+}
+
+// New returns a new [Runtime].
+//
+// <wbr> breakpoints are ignored by embedded runtimes.
+func New(opts ...Option) *Runtime {
+	c := &config{
+		options: []runtime.Option{},
+		vars:    map[string]Value{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r := &Runtime{runtime: runtime.New(c.options...)}
+	for name, value := range c.vars {
+		r.SetVar(name, value)
+	}
+	return r
+}
+
+// Eval parses and runs a program.
+func (r *Runtime) Eval(src string) error {
+	return toError(r.runtime.Eval(src))
+}
+
+// EvalContext parses and runs a program, stopping with an error matching
+// [ErrCanceled] once the context is canceled.
+func (r *Runtime) EvalContext(ctx context.Context, src string) error {
+	return toError(r.runtime.EvalContext(ctx, src))
+}
+
+// Push pushes a value onto the top of the stack.
+func (r *Runtime) Push(value Value) {
+	r.env().Stack.Push(toObject(value))
+}
+
+// Pop removes the value on top of the stack and returns it.
+func (r *Runtime) Pop() (Value, error) {
+	obj, err := r.env().Stack.Pop()
+	if err != nil {
+		return nil, toError(err)
+	}
+	return fromObject(obj), nil
+}
+
+// Peek returns the value on top of the stack without removing it.
+func (r *Runtime) Peek() (Value, error) {
+	obj, err := r.env().Stack.Peek()
+	if err != nil {
+		return nil, toError(err)
+	}
+	return fromObject(obj), nil
+}
+
+// Stack returns a copy of the values in the stack, from bottom to top.
+func (r *Runtime) Stack() []Value {
+	objects := r.env().Stack.Values()
+	values := make([]Value, 0, len(objects))
+	for _, obj := range objects {
+		values = append(values, fromObject(obj))
+	}
+	return values
+}
+
+// Var returns the value of a global variable.
+func (r *Runtime) Var(name string) (Value, error) {
+	obj, err := r.env().Vars.Get(name)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return fromObject(obj), nil
+}
+
+// SetVar sets the value of a global variable.
+func (r *Runtime) SetVar(name string, value Value) {
+	_ = r.env().Vars.Set(name, toObject(value))
+}
+
+// HostFunc is the implementation of a function written in Go.
+//
+// It receives the arguments from the bottom of the stack to the top,
+// and returns values to push into the stack in order.
+// Returning an error stops the program with a HostError.
+type HostFunc func(args []Value) ([]Value, error)
+
+// Func describes a host function to register in a module.
+type Func struct {
+	Name string
+	// Params are the types of the arguments, from the bottom of the stack to the top.
+	// Use [AnyType] to accept any type.
+	Params []Type
+	Fn     HostFunc
+	// Files marks functions that access the file system,
+	// which can only be called if [Capabilities.Files] is allowed.
+	Files bool
+}
+
+// RegisterFunc defines a variable holding a function written in Go,
+// which programs can call with <code>.
+//
+// The function takes one argument for each of the parameter types,
+// which are checked before the function is called.
+func (r *Runtime) RegisterFunc(name string, fn HostFunc, params ...Type) {
+	r.runtime.RegisterFunc(name, toHostFunc(fn), toObjectTypes(params)...)
+}
+
+// RegisterModule defines a variable holding an object whose properties
+// are functions written in Go, which programs can get with <rp>.
+func (r *Runtime) RegisterModule(name string, funcs ...Func) {
+	runtimeFuncs := make([]runtime.Func, 0, len(funcs))
+	for _, fn := range funcs {
+		runtimeFuncs = append(runtimeFuncs, runtime.Func{
+			Name:   fn.Name,
+			Params: toObjectTypes(fn.Params),
+			Fn:     toHostFunc(fn.Fn),
+			Files:  fn.Files,
+		})
+	}
+	r.runtime.RegisterModule(name, runtimeFuncs...)
+}
+
+func toHostFunc(fn HostFunc) object.HostFunc {
+	return func(args []object.Object) ([]object.Object, error) {
+		values := make([]Value, 0, len(args))
+		for _, arg := range args {
+			values = append(values, fromObject(arg))
+		}
+
+		results, err := fn(values)
+		if err != nil {
+			return nil, err
+		}
+
+		objects := make([]object.Object, 0, len(results))
+		for _, result := range results {
+			objects = append(objects, toObject(result))
+		}
+		return objects, nil
+	}
+}
+
+func toObjectTypes(types []Type) []object.ObjectType {
+	objectTypes := make([]object.ObjectType, 0, len(types))
+	for _, t := range types {
+		objectTypes = append(objectTypes, object.ObjectType(t))
+	}
+	return objectTypes
+}
+
+func (r *Runtime) env() *object.Env {
+	return r.runtime.Env()
+}
@@ -0,0 +1,69 @@
+package hypo_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/angelofallars/hypo/pkg/hypo"
+)
+
+func TestPushZeroFunction(t *testing.T) {
+	var out strings.Builder
+	r := hypo.New(hypo.WithStdout(&out))
+	r.Push(hypo.Function{})
+
+	if err := r.Eval("<output></output>"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "null\n" {
+		t.Errorf("output = %q, want %q", got, "null\n")
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	r := hypo.New()
+	err := r.Eval("<output></output>")
+
+	var hypoErr hypo.Error
+	if !errors.As(err, &hypoErr) {
+		t.Fatalf("error %v is not a hypo.Error", err)
+	}
+	if !errors.Is(err, hypo.ErrStackUnderflow) {
+		t.Errorf("error %v does not match ErrStackUnderflow", err)
+	}
+	if errors.Is(err, hypo.ErrType) {
+		t.Errorf("error %v matches ErrType", err)
+	}
+	if hypoErr.Kind() != "StackError" || hypoErr.Code() != 2 {
+		t.Errorf("kind and code = %v %v, want StackError 2", hypoErr.Kind(), hypoErr.Code())
+	}
+}
+
+func TestSandboxCapabilities(t *testing.T) {
+	r := hypo.New(hypo.WithCapabilities(hypo.SandboxCapabilities), hypo.WithStdin(strings.NewReader("x\n")))
+	if err := r.Eval("<input>"); !errors.Is(err, hypo.ErrPermission) {
+		t.Errorf("error = %v, want a PermissionError", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	err := hypo.New().Eval("<s></s><foo></foo><bar></bar>")
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("error %v is not a joined error", err)
+	}
+	parseErrors := joined.Unwrap()
+	if len(parseErrors) != 3 {
+		t.Fatalf("got %v errors, want 3:\n%v", len(parseErrors), err)
+	}
+	if !errors.Is(err, hypo.ErrParse) {
+		t.Errorf("error %v does not match ErrParse", err)
+	}
+	for _, err := range parseErrors {
+		if _, ok := err.(hypo.Error); !ok {
+			t.Errorf("error %v is not a hypo.Error", err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package hypo
+
+import (
+	"github.com/angelofallars/hypo/internal/object"
+)
+
+// Type is the type of a [Value].
+type Type string
+
+const (
+	NumberType   Type = Type(object.NumberType)
+	StringType   Type = Type(object.StringType)
+	BoolType     Type = Type(object.BoolType)
+	ObjectType   Type = Type(object.ObjType)
+	NullType     Type = Type(object.NullType)
+	ArrayType    Type = Type(object.ArrayType)
+	FunctionType Type = Type(object.FunctionType)
+
+	// AnyType matches values of any type in the parameters of a host function.
+	AnyType Type = Type(object.AnyType)
+)
+
+// Value is a value in a program. It is one of [Number], [String], [Bool],
+// [Object], [Null], [Array] or [Function].
+//
+// Values are copied when moved between Go and a [Runtime], so changing
+// an [Array] or [Object] in Go does not affect the program, and vice versa.
+type Value interface {
+	// Type returns the type of the value.
+	Type() Type
+	// String returns the string representation of the value,
+	// as printed by <output>.
+	String() string
+
+	value()
+}
+
+// Number is a Number value, created by <data>.
+type Number float64
+
+func (n Number) Type() Type     { return NumberType }
+func (n Number) String() string { return toObject(n).String() }
+func (n Number) value()         {}
+
+// String is a String value, created by <s>.
+type String string
+
+func (s String) Type() Type     { return StringType }
+func (s String) String() string { return toObject(s).String() }
+func (s String) value()         {}
+
+// Bool is a Bool value.
+type Bool bool
+
+func (b Bool) Type() Type     { return BoolType }
+func (b Bool) String() string { return toObject(b).String() }
+func (b Bool) value()         {}
+
+// Null is the null value.
+type Null struct{}
+
+func (n Null) Type() Type     { return NullType }
+func (n Null) String() string { return toObject(n).String() }
+func (n Null) value()         {}
+
+// Array is an Array value, created by <ol>.
+type Array []Value
+
+func (a Array) Type() Type     { return ArrayType }
+func (a Array) String() string { return toObject(a).String() }
+func (a Array) value()         {}
+
+// Object is an Obj value, created by <table>.
+type Object struct {
+	// Keys holds the keys of the object in insertion order.
+	Keys   []string
+	Values map[string]Value
+}
+
+// NewObject returns a new, empty [Object].
+func NewObject() *Object {
+	return &Object{
+		Keys:   []string{},
+		Values: map[string]Value{},
+	}
+}
+
+// Set stores a value under a key. New keys are appended to the key order.
+func (o *Object) Set(key string, value Value) {
+	if _, ok := o.Values[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Values[key] = value
+}
+
+func (o *Object) Type() Type     { return ObjectType }
+func (o *Object) String() string { return toObject(o).String() }
+func (o *Object) value()         {}
+
+// Function is a function created by <dfn>, or a host function.
+//
+// Functions cannot be created from Go, but can be passed back into the [Runtime].
+// Use [Runtime.RegisterFunc] to define functions written in Go.
+// The zero Function is converted to [Null] when passed to the [Runtime].
+type Function struct {
+	function object.Object
+}
+
+func (f Function) Type() Type { return FunctionType }
+func (f Function) value()     {}
+
+func (f Function) String() string {
+	if f.function == nil {
+		return Null{}.String()
+	}
+	return f.function.String()
+}
+
+// toObject converts a [Value] into a runtime object.
+func toObject(value Value) object.Object {
+	switch value := value.(type) {
+	case Number:
+		return object.NewNumber(float64(value))
+	case String:
+		return &object.String{Value: string(value)}
+	case Bool:
+		return object.NewBool(bool(value))
+	case Array:
+		elements := make([]object.Object, 0, len(value))
+		for _, element := range value {
+			elements = append(elements, toObject(element))
+		}
+		return &object.Array{Value: elements}
+	case *Object:
+		obj := object.NewObj()
+		for _, key := range value.Keys {
+			obj.Set(key, toObject(value.Values[key]))
+		}
+		return obj
+	case Function:
+		if value.function != nil {
+			return value.function
+		}
+	}
+	return &object.Null{}
+}
+
+// fromObject converts a runtime object into a [Value].
+func fromObject(obj object.Object) Value {
+	switch obj := obj.(type) {
+	case *object.Number:
+		return Number(obj.Value)
+	case *object.String:
+		return String(obj.Value)
+	case *object.Bool:
+		return Bool(obj.Value)
+	case *object.Array:
+		array := make(Array, 0, len(obj.Value))
+		for _, element := range obj.Value {
+			array = append(array, fromObject(element))
+		}
+		return array
+	case *object.Obj:
+		value := NewObject()
+		for _, key := range obj.Keys {
+			value.Set(key, fromObject(obj.Value[key]))
+		}
+		return value
+	case *object.Function, *object.HostFunction:
+		return Function{function: obj}
+	}
+	return Null{}
+}